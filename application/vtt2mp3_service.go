@@ -1,11 +1,14 @@
 package application
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"time"
+	"sort"
+	"strings"
 	"vtt2mp3/domain/tts"
 	"vtt2mp3/domain/vtt"
 )
@@ -15,8 +18,10 @@ const (
 	errParseVTT     = "VTTファイルの解析に失敗: %w"
 	errCreateOutput = "出力ファイルの作成に失敗: %w"
 	errCloseOutput  = "出力ファイルの閉じるのに失敗: %w"
+	errRenameOutput = "出力ファイルの保存に失敗: %w"
 	errSynthesize   = "音声合成に失敗: %w"
 	errCreateVideo  = "動画作成に失敗: %w"
+	errTimeline     = "タイミングの変換に失敗: %w"
 )
 
 // VTT2MP3Service は字幕ファイル(VTT)からMP3音声ファイルへの変換を行うサービス
@@ -33,38 +38,198 @@ func NewVTT2MP3Service(ttsService tts.TextToSpeechService) *VTT2MP3Service {
 
 // ConvertOptions はVTTからMP3またはMP4への変換オプションを表す
 type ConvertOptions struct {
-	InputFile     string // 入力VTTファイルのパス
-	OutputFile    string // 出力MP3またはMP4ファイルのパス
-	LanguageCode  string // 音声合成に使用する言語コード
-	IsVideoOutput bool   // 出力が動画かどうか
+	InputFile     string     // 入力字幕ファイルのパス
+	OutputFile    string     // 出力MP3またはMP4ファイルのパス
+	InputFormat   vtt.Format // 入力の形式（FormatUnknownの場合は拡張子または内容から判別）
+	InputCharset  string     // 入力の文字コード（空または"auto"の場合は内容から判別）
+	LanguageCode  string     // 音声合成に使用する言語コード
+	VoiceName     string     // 音声合成に使用する声の名前（空の場合はプロバイダーが選択）
+	IsVideoOutput bool       // 出力が動画かどうか
+	// SpeakerVoices は<v>タグの話者名（またはスタイル名）から声への対応（nilの場合は話者ごとに自動で割り当てる）
+	SpeakerVoices *tts.SpeakerVoices
+	// Timeline は字幕全体のタイミングに適用する変換（オフセット、拡大縮小、フレームレート変換、切り出し）
+	Timeline vtt.TimelineOptions
+	// Normalizer は読み上げる前にテキストを整える処理（nilの場合は言語ごとの既定の処理）
+	// 字幕の表示には元のテキストを使用する
+	Normalizer tts.Normalizer
+	// Prosody はすべての字幕に適用する音声の設定（話す速さ、声の高さ、音量など）
+	Prosody tts.Prosody
+	// CueProsody はキュー識別子ごとの音声の設定（全体と話者ごとの設定より優先）
+	CueProsody tts.CueProsody
+	// Lexicon は読み上げるテキストに適用する発音辞書（nilの場合は適用しない）
+	Lexicon *tts.Lexicon
+	// AutoSSML は字幕のテキストから自動でSSMLを生成するかどうか
+	// 省略記号と改行で間を空け、日付・電話番号・数値の読み方を指定し、<b>と<i>を強調して読み上げる
+	AutoSSML bool
+	// Synthesis は音声の合成と結合の設定（音声が重なる場合の扱いなど）
+	Synthesis tts.SynthesisOptions
+	// Segmentation は音声合成の前に字幕を文単位に再分割する設定（nilの場合は字幕ごとに合成する）
+	Segmentation *vtt.SegmentOptions
 }
 
-// Convert はVTTファイルをMP3ファイルまたはMP4ファイルに変換する
-func (s *VTT2MP3Service) Convert(options ConvertOptions) error {
-	// VTTファイルを解析
-	vttFile, err := vtt.ParseVTTFile(options.InputFile)
+// ListVoices は音声合成に使用できる声の一覧を名前順に返す
+// languageCodeが空でない場合はその言語に対応する声のみを返す
+func (s *VTT2MP3Service) ListVoices(ctx context.Context, languageCode string) ([]tts.Voice, error) {
+	voices, err := s.ttsService.ListVoices(ctx, languageCode)
 	if err != nil {
-		return fmt.Errorf(errParseVTT, err)
+		return nil, err
+	}
+
+	sort.Slice(voices, func(i, j int) bool {
+		return voices[i].Name < voices[j].Name
+	})
+	return voices, nil
+}
+
+// Convert は字幕ファイルをMP3ファイルまたはMP4ファイルに変換する
+// 音声の重なりのために調整したタイミングなどを記録したレポートを返す
+// コンテキストが取り消された場合は音声合成とffmpegの処理を中断し、一時ファイルを削除してエラーを返す
+func (s *VTT2MP3Service) Convert(ctx context.Context, options ConvertOptions) (*tts.SynthesisReport, error) {
+	// 字幕ファイルを解析
+	inputFormat := options.InputFormat
+	if inputFormat == vtt.FormatUnknown {
+		inputFormat = vtt.FormatFromExtension(options.InputFile)
+	}
+	vttFile, err := parseInputFile(options.InputFile, inputFormat, options.InputCharset)
+	if err != nil {
+		return nil, fmt.Errorf(errParseVTT, err)
+	}
+
+	// タイミングを変換
+	if err := options.Timeline.Apply(vttFile); err != nil {
+		return nil, fmt.Errorf(errTimeline, err)
+	}
+
+	return s.convertToOutputFile(ctx, vttFile, options)
+}
+
+// ConvertStreamToFile はリーダーから読み込んだ字幕をMP3またはMP4に変換し、options.OutputFileに書き込む
+// Convertと同様に、変換が成功した場合のみ出力ファイルを置き換える
+func (s *VTT2MP3Service) ConvertStreamToFile(ctx context.Context, input io.Reader, options ConvertOptions) (*tts.SynthesisReport, error) {
+	// 字幕データを解析
+	vttFile, err := parseInput(input, options.InputFormat, options.InputCharset)
+	if err != nil {
+		return nil, fmt.Errorf(errParseVTT, err)
+	}
+
+	// タイミングを変換
+	if err := options.Timeline.Apply(vttFile); err != nil {
+		return nil, fmt.Errorf(errTimeline, err)
+	}
+
+	return s.convertToOutputFile(ctx, vttFile, options)
+}
+
+// convertToOutputFile は字幕をMP3またはMP4に変換してoptions.OutputFileに書き込む
+// 出力先と同じディレクトリの一時ファイルに書き込み、成功した場合のみ出力ファイルに置き換える
+func (s *VTT2MP3Service) convertToOutputFile(ctx context.Context, vttFile *vtt.VTTFile, options ConvertOptions) (*tts.SynthesisReport, error) {
+	var report *tts.SynthesisReport
+	err := writeOutputFile(options.OutputFile, func(tempPath string) error {
+		var err error
+		if options.IsVideoOutput {
+			// 動画出力の場合
+			report, err = s.convertToVideo(ctx, vttFile, tempPath, options)
+		} else {
+			// 音声出力の場合（MP3）
+			report, err = s.convertToAudioFile(ctx, vttFile, tempPath, options)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// writeOutputFile は出力先と同じディレクトリに一時ファイルを作成してwriteに渡し、成功した場合のみ出力先のファイル名に変更する
+// 一時ファイルは出力先と同じ拡張子にする（ffmpegは拡張子から出力の形式を判断する）
+// 書き込みに失敗した場合やコンテキストが取り消された場合は一時ファイルを削除し、出力先の既存のファイルは変更しない
+func writeOutputFile(outputPath string, write func(tempPath string) error) (err error) {
+	dir, name := filepath.Dir(outputPath), filepath.Base(outputPath)
+	ext := filepath.Ext(name)
+	tempFile, err := os.CreateTemp(dir, "."+strings.TrimSuffix(name, ext)+".*"+ext)
+	if err != nil {
+		return fmt.Errorf(errCreateOutput, err)
+	}
+	tempPath := tempFile.Name()
+	defer func() {
+		if err != nil {
+			if removeErr := os.Remove(tempPath); removeErr != nil && !os.IsNotExist(removeErr) {
+				fmt.Fprintf(os.Stderr, "一時ファイルの削除に失敗しました: %v\n", removeErr)
+			}
+		}
+	}()
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf(errCloseOutput, err)
+	}
+	// os.CreateTempは所有者のみが読み書きできるファイルを作成するため、通常の出力ファイルと同じ権限にする
+	if err := os.Chmod(tempPath, 0644); err != nil {
+		return fmt.Errorf(errCreateOutput, err)
+	}
+
+	if err := write(tempPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, outputPath); err != nil {
+		return fmt.Errorf(errRenameOutput, err)
+	}
+	return nil
+}
+
+// ConvertStream はリーダーから読み込んだ字幕をMP3またはMP4に変換し、ライターに書き込む
+// 音声はffmpegの出力をそのままライターに流し込む
+func (s *VTT2MP3Service) ConvertStream(ctx context.Context, input io.Reader, output io.Writer, options ConvertOptions) (*tts.SynthesisReport, error) {
+	// 字幕データを解析
+	vttFile, err := parseInput(input, options.InputFormat, options.InputCharset)
+	if err != nil {
+		return nil, fmt.Errorf(errParseVTT, err)
+	}
+
+	// タイミングを変換
+	if err := options.Timeline.Apply(vttFile); err != nil {
+		return nil, fmt.Errorf(errTimeline, err)
 	}
 
 	// 動画出力の場合
 	if options.IsVideoOutput {
-		return s.convertToVideo(vttFile, options)
+		return s.convertToVideoStream(ctx, vttFile, output, options)
 	}
 
 	// 音声出力の場合（MP3）
-	return s.convertToAudio(vttFile, options)
+	return s.convertToAudio(ctx, vttFile, output, options)
 }
 
-// convertToAudio はVTTファイルをMP3ファイルに変換する
-func (s *VTT2MP3Service) convertToAudio(vttFile *vtt.VTTFile, options ConvertOptions) error {
-	// 字幕からTTSリクエストを作成
-	ttsRequests := s.createTTSRequests(vttFile, options.LanguageCode)
+// parseInputFile は字幕ファイルを指定された形式で解析する
+func parseInputFile(filePath string, format vtt.Format, charset string) (vttFile *vtt.VTTFile, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return parseInput(file, format, charset)
+}
 
+// parseInput は字幕データをUTF-8に変換してから指定された形式で解析する
+func parseInput(input io.Reader, format vtt.Format, charset string) (*vtt.VTTFile, error) {
+	decoded, err := vtt.NewDecodingReader(input, charset)
+	if err != nil {
+		return nil, err
+	}
+	return vtt.Parse(decoded, format)
+}
+
+// convertToAudioFile はVTTファイルをMP3ファイルに変換する
+func (s *VTT2MP3Service) convertToAudioFile(ctx context.Context, vttFile *vtt.VTTFile, outputPath string, options ConvertOptions) (report *tts.SynthesisReport, err error) {
 	// 出力ファイルを作成
-	outputFile, err := os.Create(options.OutputFile)
+	outputFile, err := os.Create(outputPath)
 	if err != nil {
-		return fmt.Errorf(errCreateOutput, err)
+		return nil, fmt.Errorf(errCreateOutput, err)
 	}
 	defer func() {
 		closeErr := outputFile.Close()
@@ -73,26 +238,34 @@ func (s *VTT2MP3Service) convertToAudio(vttFile *vtt.VTTFile, options ConvertOpt
 		}
 	}()
 
-	// 音声を合成して出力ファイルに書き込む
-	if err := s.ttsService.SynthesizeMultiple(ttsRequests, outputFile); err != nil {
-		return fmt.Errorf(errSynthesize, err)
+	return s.convertToAudio(ctx, vttFile, outputFile, options)
+}
+
+// convertToAudio はVTTファイルをMP3音声に変換してライターに書き込む
+func (s *VTT2MP3Service) convertToAudio(ctx context.Context, vttFile *vtt.VTTFile, output io.Writer, options ConvertOptions) (*tts.SynthesisReport, error) {
+	// 字幕からTTSリクエストを作成
+	ttsRequests, err := s.createTTSRequests(vttFile, options)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	// 音声を合成して出力に書き込む
+	report, err := s.ttsService.SynthesizeMultiple(ctx, ttsRequests, output, options.Synthesis)
+	if err != nil {
+		return nil, fmt.Errorf(errSynthesize, err)
+	}
+
+	return report, nil
 }
 
 // convertToVideo はVTTファイルをMP4動画ファイルに変換する
-func (s *VTT2MP3Service) convertToVideo(vttFile *vtt.VTTFile, options ConvertOptions) error {
+func (s *VTT2MP3Service) convertToVideo(ctx context.Context, vttFile *vtt.VTTFile, outputPath string, options ConvertOptions) (*tts.SynthesisReport, error) {
 	// 一時的なMP3ファイルを作成
 	tempDir, err := os.MkdirTemp("", "vtt2mp4_")
 	if err != nil {
-		return fmt.Errorf("一時ディレクトリの作成に失敗: %w", err)
+		return nil, fmt.Errorf("一時ディレクトリの作成に失敗: %w", err)
 	}
-	defer func() {
-		if err := os.RemoveAll(tempDir); err != nil {
-			fmt.Printf("一時ディレクトリの削除に失敗しました: %v\n", err)
-		}
-	}()
+	defer removeTempDir(tempDir)
 
 	// 一時的なMP3ファイルのパス
 	tempMP3 := filepath.Join(tempDir, "audio.mp3")
@@ -100,99 +273,231 @@ func (s *VTT2MP3Service) convertToVideo(vttFile *vtt.VTTFile, options ConvertOpt
 	// 一時的なVTTファイルのパス
 	tempVTT := filepath.Join(tempDir, "subtitles.vtt")
 
-	// 一時的なMP3ファイルを作成するためのオプション
-	audioOptions := ConvertOptions{
-		InputFile:     options.InputFile,
-		OutputFile:    tempMP3,
-		LanguageCode:  options.LanguageCode,
-		IsVideoOutput: false,
-	}
-
 	// 音声を生成
-	if err := s.convertToAudio(vttFile, audioOptions); err != nil {
-		return fmt.Errorf("音声生成に失敗: %w", err)
+	report, err := s.convertToAudioFile(ctx, vttFile, tempMP3, options)
+	if err != nil {
+		return nil, fmt.Errorf("音声生成に失敗: %w", err)
 	}
 
-	// 一時的なVTTファイルを作成
-	if err := s.writeVTTFile(vttFile, tempVTT); err != nil {
-		return fmt.Errorf("字幕ファイルの作成に失敗: %w", err)
+	// 一時的なVTTファイルを作成（SSMLの字幕は読み上げるテキストとして表示する）
+	if err := vtt.WriteVTTFile(tempVTT, displayVTTFile(vttFile)); err != nil {
+		return nil, fmt.Errorf("字幕ファイルの作成に失敗: %w", err)
 	}
 
 	// FFmpegを使用して動画を生成
-	if err := s.generateVideo(tempMP3, tempVTT, options.OutputFile); err != nil {
-		return fmt.Errorf(errCreateVideo, err)
+	if err := s.generateVideo(ctx, tempMP3, tempVTT, outputPath); err != nil {
+		return nil, fmt.Errorf(errCreateVideo, err)
 	}
 
-	return nil
+	return report, nil
+}
+
+// convertToVideoStream はVTTファイルをMP4動画に変換してライターに書き込む
+// MP4の書き込みにはシーク可能な出力が必要なため、一時ファイルに生成してからコピーする
+func (s *VTT2MP3Service) convertToVideoStream(ctx context.Context, vttFile *vtt.VTTFile, output io.Writer, options ConvertOptions) (report *tts.SynthesisReport, err error) {
+	tempDir, err := os.MkdirTemp("", "vtt2mp4_out_")
+	if err != nil {
+		return nil, fmt.Errorf("一時ディレクトリの作成に失敗: %w", err)
+	}
+	defer removeTempDir(tempDir)
+
+	tempMP4 := filepath.Join(tempDir, "video.mp4")
+	report, err = s.convertToVideo(ctx, vttFile, tempMP4, options)
+	if err != nil {
+		return nil, err
+	}
+
+	video, err := os.Open(tempMP4)
+	if err != nil {
+		return nil, fmt.Errorf(errCreateVideo, err)
+	}
+	defer func() {
+		closeErr := video.Close()
+		if closeErr != nil && err == nil {
+			err = fmt.Errorf(errCreateVideo, closeErr)
+		}
+	}()
+
+	if _, err := io.Copy(output, video); err != nil {
+		return nil, fmt.Errorf("動画の書き込みに失敗: %w", err)
+	}
+
+	return report, nil
+}
+
+// subtitleSSML は字幕が<speak>で始まるSSMLとして記述されている場合にSSMLドキュメントを返す
+// WebVTTではキューテキストにそのまま記述されるが、SRTなどの形式ではエスケープされているため、
+// マークアップを除いてエスケープを戻したテキストも確認する
+func subtitleSSML(subtitle vtt.Subtitle) (string, bool) {
+	if text := strings.TrimSpace(subtitle.Text); tts.IsSSML(text) {
+		return text, true
+	}
+	if text := strings.TrimSpace(subtitle.PlainText()); tts.IsSSML(text) {
+		return text, true
+	}
+	return "", false
+}
+
+// displayVTTFile は動画に埋め込むための字幕を返す
+// SSMLの字幕は要素を取り除いたテキストに置き換え、それ以外は元のまま保持する
+func displayVTTFile(vttFile *vtt.VTTFile) *vtt.VTTFile {
+	display := *vttFile
+	display.Subtitles = make([]vtt.Subtitle, len(vttFile.Subtitles))
+	for i, subtitle := range vttFile.Subtitles {
+		if ssml, ok := subtitleSSML(subtitle); ok {
+			subtitle.Text = vtt.EscapeText(tts.StripSSML(ssml))
+		}
+		display.Subtitles[i] = subtitle
+	}
+	return &display
+}
+
+// removeTempDir は一時ディレクトリを削除する
+func removeTempDir(tempDir string) {
+	if err := os.RemoveAll(tempDir); err != nil {
+		fmt.Fprintf(os.Stderr, "一時ディレクトリの削除に失敗しました: %v\n", err)
+	}
 }
 
 // createTTSRequests は字幕データからTTSリクエストのスライスを作成する
-func (s *VTT2MP3Service) createTTSRequests(vttFile *vtt.VTTFile, languageCode string) []tts.TextToSpeechRequest {
+// <v>タグで話者が指定された字幕には、話者ごとに一貫した声を割り当てる
+// <speak>で始まる字幕はSSMLとしてそのまま送信し、送信するSSMLはすべて事前に検証する
+func (s *VTT2MP3Service) createTTSRequests(vttFile *vtt.VTTFile, options ConvertOptions) ([]tts.TextToSpeechRequest, error) {
 	ttsRequests := make([]tts.TextToSpeechRequest, 0, len(vttFile.Subtitles))
 
-	for _, subtitle := range vttFile.Subtitles {
-		ttsRequests = append(ttsRequests, tts.TextToSpeechRequest{
-			Input: tts.SynthesisInput{
-				Text: subtitle.Text,
-			},
+	speakerVoices := options.SpeakerVoices
+	if speakerVoices == nil {
+		speakerVoices = tts.NewSpeakerVoices(nil, nil)
+	}
+
+	normalizer := options.Normalizer
+	if normalizer == nil {
+		normalizer = tts.DefaultNormalizers(options.LanguageCode)
+	}
+
+	// 文の途中で区切られた字幕を結合し、長すぎる字幕を分割する（動画の字幕は元の区切りのまま）
+	subtitles := vttFile.Subtitles
+	sources := make([]int, len(subtitles))
+	for i := range sources {
+		sources[i] = i
+	}
+	if options.Segmentation != nil {
+		segmentOptions := *options.Segmentation
+		if segmentOptions.LanguageCode == "" {
+			segmentOptions.LanguageCode = options.LanguageCode
+		}
+		subtitles, sources = vtt.SegmentWithSources(subtitles, segmentOptions)
+	}
+
+	for i, subtitle := range subtitles {
+		// マークアップを除き、読み上げ用に整えたテキストを読み上げる（空の字幕は読み上げない）
+		// SSMLを自動で生成する場合は強調の区間に目印を付けたまま整える
+		ssmlSource := ""
+		text := normalizer.Normalize(subtitle.PlainText())
+		if options.AutoSSML {
+			ssmlSource = normalizer.Normalize(emphasisMarkedText(subtitle.CueText()))
+			text = tts.StripEmphasis(ssmlSource)
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		input := tts.SynthesisInput{Text: text}
+		if ssml, ok := subtitleSSML(subtitle); ok {
+			input.SSML = ssml
+		}
+
+		request := tts.TextToSpeechRequest{
+			Input: input,
 			Voice: tts.VoiceSelectionParams{
-				LanguageCode: languageCode,
+				LanguageCode: options.LanguageCode,
 				Gender:       tts.Neutral,
 			},
 			AudioConfig: tts.AudioConfig{
 				AudioFormat: tts.MP3,
 			},
 			StartTime: subtitle.StartTime,
-		})
-	}
+			EndTime:   subtitle.EndTime,
+			CueID:     subtitle.ID,
+			CueNumber: sources[i] + 1,
+		}
+		options.Prosody.Apply(&request.AudioConfig)
 
-	return ttsRequests
-}
+		// 全体で指定された声を適用（声の名前から言語コードを補う）
+		if options.VoiceName != "" {
+			tts.VoiceProfile{Name: options.VoiceName, Gender: tts.Neutral}.Apply(&request.Voice, &request.AudioConfig)
+		}
 
-// writeVTTFile はVTTファイルを指定されたパスに書き込む
-func (s *VTT2MP3Service) writeVTTFile(vttFile *vtt.VTTFile, outputPath string) error {
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("VTTファイルの作成に失敗: %w", err)
-	}
-	defer func() {
-		if err := file.Close(); err != nil {
-			fmt.Printf("ファイルの閉じるのに失敗しました: %v\n", err)
+		// 話者に対応する声を適用（話者がない場合はマッピングされたスタイル名の声を使用）
+		// 話者の声は全体で指定された声より優先し、名前のない声（プールの性別のみの声など）でも全体の声の名前は使わない
+		var profile tts.VoiceProfile
+		hasProfile := false
+		if speaker := subtitle.Speaker(); speaker != "" {
+			profile, hasProfile = speakerVoices.VoiceFor(speaker), true
+		} else if subtitle.Style != "" {
+			profile, hasProfile = speakerVoices.Lookup(subtitle.Style)
+		}
+		if hasProfile {
+			request.Voice.Name = ""
+			profile.Apply(&request.Voice, &request.AudioConfig)
 		}
-	}()
 
-	// VTTヘッダーを書き込む
-	if _, err := file.WriteString("WEBVTT\n\n"); err != nil {
-		return fmt.Errorf("VTTヘッダーの書き込みに失敗: %w", err)
-	}
+		// キューごとの音声の設定を適用
+		if prosody, ok := options.CueProsody.Lookup(subtitle.ID); ok {
+			prosody.Apply(&request.AudioConfig)
+		}
 
-	// 各字幕を書き込む
-	for i, subtitle := range vttFile.Subtitles {
-		// タイムスタンプを書き込む
-		startTime := formatTimestamp(subtitle.StartTime)
-		endTime := formatTimestamp(subtitle.EndTime)
+		// 声の言語に合わせて発音辞書を適用
+		if options.AutoSSML && request.Input.SSML == "" {
+			request.Input.SSML = tts.GenerateSSML(ssmlSource, request.Voice.LanguageCode, options.Lexicon)
+		} else {
+			options.Lexicon.Apply(&request)
+		}
 
-		if _, err := fmt.Fprintf(file, "%d\n%s --> %s\n%s\n\n",
-			i+1, startTime, endTime, subtitle.Text); err != nil {
-			return fmt.Errorf("字幕の書き込みに失敗: %w", err)
+		if request.Input.SSML != "" {
+			if err := tts.ValidateSSML(request.Input.SSML); err != nil {
+				return nil, fmt.Errorf("字幕 %s のSSMLが不正です: %w", tts.CueLabel(sources[i], subtitle.ID), err)
+			}
 		}
+
+		ttsRequests = append(ttsRequests, request)
 	}
 
-	return nil
+	return ttsRequests, nil
 }
 
-// formatTimestamp は時間をVTTのタイムスタンプ形式（HH:MM:SS.mmm）に変換する
-func formatTimestamp(duration time.Duration) string {
-	hours := int(duration.Hours())
-	minutes := int(duration.Minutes()) % 60
-	seconds := int(duration.Seconds()) % 60
-	milliseconds := int(duration.Milliseconds()) % 1000
+// emphasisMarkedText はキューテキストからマークアップを除き、強調（<b>, <i>, <u>）の区間に目印を付けたテキストを返す
+func emphasisMarkedText(root *vtt.CueNode) string {
+	var builder strings.Builder
+	var write func(node *vtt.CueNode, emphasized bool)
+	write = func(node *vtt.CueNode, emphasized bool) {
+		switch node.Type {
+		case vtt.TextNode:
+			builder.WriteString(node.Text)
+			return
+		case vtt.RubyTextNode:
+			return
+		}
 
-	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds)
+		// 入れ子の強調は外側の区間にまとめる
+		marked := node.IsEmphasis() && !emphasized
+		if marked {
+			builder.WriteString(tts.EmphasisStart)
+		}
+		for _, child := range node.Children {
+			write(child, emphasized || marked)
+		}
+		if marked {
+			builder.WriteString(tts.EmphasisEnd)
+		}
+	}
+	write(root, false)
+	return builder.String()
 }
 
 // generateVideo はMP3音声ファイルとVTT字幕ファイルからMP4動画を生成する
-func (s *VTT2MP3Service) generateVideo(audioFile, subtitleFile, outputFile string) error {
+// コンテキストが取り消された場合はffmpegを終了する
+func (s *VTT2MP3Service) generateVideo(ctx context.Context, audioFile, subtitleFile, outputFile string) error {
 	// FFmpegコマンドを構築
 	// 1. 黒い背景の動画を生成
 	// 2. 音声ファイルを追加
@@ -208,7 +513,8 @@ func (s *VTT2MP3Service) generateVideo(audioFile, subtitleFile, outputFile strin
 		"drawtext=fontsize=48:fontcolor=white:x=(w-text_w)/2:y=(h-text_h)/2:" +
 		"text='%{pts\\:hms}.%{eif\\:mod(floor(t*10),10)\\:d}':box=1:boxcolor=black@0.5:boxborderw=5:rate=10"
 
-	cmd := exec.Command(
+	cmd := exec.CommandContext(
+		ctx,
 		"ffmpeg",
 		"-y",          // 既存のファイルを上書き
 		"-f", "lavfi", // 入力フォーマットとしてlavfiを使用
@@ -223,6 +529,9 @@ func (s *VTT2MP3Service) generateVideo(audioFile, subtitleFile, outputFile strin
 
 	// コマンドを実行
 	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if err != nil {
 		return fmt.Errorf("FFmpegの実行に失敗: %w, 出力: %s", err, string(output))
 	}
@@ -0,0 +1,321 @@
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"vtt2mp3/domain/tts"
+	"vtt2mp3/domain/vtt"
+)
+
+func TestSubtitleSSML(t *testing.T) {
+	tests := []struct {
+		name   string
+		format vtt.Format
+		input  string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "webvtt",
+			format: vtt.FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n<speak>Hi <break time=\"1s\"/> there</speak>\n",
+			want:   `<speak>Hi <break time="1s"/> there</speak>`,
+			wantOK: true,
+		},
+		{
+			name:   "srt",
+			format: vtt.FormatSRT,
+			input:  "1\n00:00:01,000 --> 00:00:02,000\n<speak>Tom &amp; Jerry<break time=\"1s\"/></speak>\n",
+			want:   `<speak>Tom &amp; Jerry<break time="1s"/></speak>`,
+			wantOK: true,
+		},
+		{
+			name:   "plain text",
+			format: vtt.FormatSRT,
+			input:  "1\n00:00:01,000 --> 00:00:02,000\n<i>Hello</i>\n",
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := vtt.Parse(strings.NewReader(tt.input), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := subtitleSSML(file.Subtitles[0])
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("subtitleSSML() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDisplayVTTFile(t *testing.T) {
+	file := &vtt.VTTFile{Subtitles: []vtt.Subtitle{
+		{ID: "1", Text: `<speak>Tom &amp; Jerry<break time="1s"/></speak>`},
+		{ID: "2", Text: "<i>Hello</i>"},
+	}}
+
+	display := displayVTTFile(file)
+	if got := display.Subtitles[0].Text; got != "Tom &amp; Jerry" {
+		t.Errorf("SSML cue text = %q, want %q", got, "Tom &amp; Jerry")
+	}
+	if got := display.Subtitles[1].Text; got != "<i>Hello</i>" {
+		t.Errorf("plain cue text = %q, want %q", got, "<i>Hello</i>")
+	}
+	if file.Subtitles[0].Text != `<speak>Tom &amp; Jerry<break time="1s"/></speak>` {
+		t.Error("original subtitles were modified")
+	}
+}
+
+func TestCreateTTSRequestsCueNumber(t *testing.T) {
+	file := &vtt.VTTFile{Subtitles: []vtt.Subtitle{
+		{ID: "a", StartTime: 0, EndTime: time.Second, Text: "<i></i>"},
+		{ID: "b", StartTime: time.Second, EndTime: 2 * time.Second, Text: "This is"},
+		{ID: "c", StartTime: 2 * time.Second, EndTime: 3 * time.Second, Text: "one."},
+		{ID: "d", StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "Two."},
+	}}
+
+	tests := []struct {
+		name         string
+		segmentation *vtt.SegmentOptions
+		wantNumbers  []int
+	}{
+		{"per cue", nil, []int{2, 3, 4}},
+		{"segmented", &vtt.SegmentOptions{}, []int{2, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &VTT2MP3Service{}
+			requests, err := service.createTTSRequests(file, ConvertOptions{LanguageCode: "en-US", Segmentation: tt.segmentation})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(requests) != len(tt.wantNumbers) {
+				t.Fatalf("got %d requests, want %d", len(requests), len(tt.wantNumbers))
+			}
+			for i, want := range tt.wantNumbers {
+				if requests[i].CueNumber != want {
+					t.Errorf("request %d (%s) CueNumber = %d, want %d", i, requests[i].CueID, requests[i].CueNumber, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteOutputFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		writeErr error
+		want     string
+	}{
+		{"success replaces output", nil, "new"},
+		{"failure keeps output", context.Canceled, "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputPath := filepath.Join(dir, "out.mp3")
+			if err := os.WriteFile(outputPath, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := writeOutputFile(outputPath, func(tempPath string) error {
+				if filepath.Dir(tempPath) != dir || filepath.Ext(tempPath) != ".mp3" {
+					t.Errorf("temp file %q is not an .mp3 file in %q", tempPath, dir)
+				}
+				if err := os.WriteFile(tempPath, []byte("new"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return tt.writeErr
+			})
+			if !errors.Is(err, tt.writeErr) {
+				t.Fatalf("writeOutputFile() error = %v, want %v", err, tt.writeErr)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory has %d entries, want only the output file", len(entries))
+			}
+		})
+	}
+}
+
+// fakeTTSService は合成したテキストをそのまま出力に書き込むテスト用の音声合成サービスです
+type fakeTTSService struct {
+	err      error
+	requests []tts.TextToSpeechRequest
+}
+
+func (f *fakeTTSService) SynthesizeSpeech(ctx context.Context, request tts.TextToSpeechRequest) ([]byte, error) {
+	return []byte(request.Input.Text), f.err
+}
+
+func (f *fakeTTSService) SynthesizeMultiple(ctx context.Context, requests []tts.TextToSpeechRequest, output io.Writer, options tts.SynthesisOptions) (*tts.SynthesisReport, error) {
+	f.requests = requests
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, request := range requests {
+		if _, err := io.WriteString(output, request.Input.Text+"\n"); err != nil {
+			return nil, err
+		}
+	}
+	return &tts.SynthesisReport{}, nil
+}
+
+func (f *fakeTTSService) ListVoices(ctx context.Context, languageCode string) ([]tts.Voice, error) {
+	return nil, f.err
+}
+
+func TestConvertStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  vtt.Format
+		charset string
+		want    string
+	}{
+		{
+			name:  "webvtt detected from content",
+			input: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n\n00:00:03.000 --> 00:00:04.000\n<i>world</i>\n",
+			want:  "Hello\nworld\n",
+		},
+		{
+			name:   "srt with explicit format",
+			input:  "1\r\n00:00:01,000 --> 00:00:02,000\r\nTom & Jerry\r\n",
+			format: vtt.FormatSRT,
+			want:   "Tom & Jerry\n",
+		},
+		{
+			name:    "explicit charset",
+			input:   "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\ncaf\xe9\n",
+			charset: "windows-1252",
+			want:    "café\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewVTT2MP3Service(&fakeTTSService{})
+			options := ConvertOptions{
+				InputFormat:  tt.format,
+				InputCharset: tt.charset,
+				LanguageCode: "en-US",
+				Normalizer:   tts.NormalizerChain{},
+			}
+
+			var output bytes.Buffer
+			if _, err := service.ConvertStream(context.Background(), strings.NewReader(tt.input), &output, options); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != tt.want {
+				t.Errorf("output = %q, want %q", output.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertStreamToFile(t *testing.T) {
+	const input = "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n"
+
+	tests := []struct {
+		name    string
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{"success replaces output", nil, "Hello\n", false},
+		{"failure keeps output", errors.New("synthesis failed"), "old", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputPath := filepath.Join(dir, "out.mp3")
+			if err := os.WriteFile(outputPath, []byte("old"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			service := NewVTT2MP3Service(&fakeTTSService{err: tt.err})
+			options := ConvertOptions{OutputFile: outputPath, LanguageCode: "en-US", Normalizer: tts.NormalizerChain{}}
+			_, err := service.ConvertStreamToFile(context.Background(), strings.NewReader(input), options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ConvertStreamToFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.want {
+				t.Errorf("output = %q, want %q", content, tt.want)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("directory has %d entries, want only the output file", len(entries))
+			}
+		})
+	}
+}
+
+func TestCreateTTSRequestsVoices(t *testing.T) {
+	file := &vtt.VTTFile{Subtitles: []vtt.Subtitle{
+		{StartTime: 0, EndTime: time.Second, Text: "<v Alice>Hello"},
+		{StartTime: time.Second, EndTime: 2 * time.Second, Text: "<v Bob>Hi"},
+		{StartTime: 2 * time.Second, EndTime: 3 * time.Second, Text: "Narration"},
+		{StartTime: 3 * time.Second, EndTime: 4 * time.Second, Text: "<v Carol>Hey"},
+	}}
+	speakerVoices := tts.NewSpeakerVoices(map[string]tts.VoiceProfile{
+		"Carol": {Name: "ja-JP-Neural2-C", Gender: tts.Female},
+	}, nil)
+
+	service := &VTT2MP3Service{}
+	requests, err := service.createTTSRequests(file, ConvertOptions{
+		LanguageCode:  "en-US",
+		VoiceName:     "ja-JP-Neural2-B",
+		SpeakerVoices: speakerVoices,
+		Normalizer:    tts.NormalizerChain{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []tts.VoiceSelectionParams{
+		{LanguageCode: "ja-JP", Gender: tts.Female},
+		{LanguageCode: "ja-JP", Gender: tts.Male},
+		{LanguageCode: "ja-JP", Name: "ja-JP-Neural2-B", Gender: tts.Neutral},
+		{LanguageCode: "ja-JP", Name: "ja-JP-Neural2-C", Gender: tts.Female},
+	}
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(requests), len(want))
+	}
+	for i := range want {
+		if requests[i].Voice != want[i] {
+			t.Errorf("request %d voice = %+v, want %+v", i, requests[i].Voice, want[i])
+		}
+	}
+}
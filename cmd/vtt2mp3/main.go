@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"vtt2mp3/application"
 	"vtt2mp3/infrastructure/google"
 	"vtt2mp3/presentation"
@@ -26,8 +29,8 @@ func handleError(err error) {
 	os.Exit(exitCode)
 }
 
-// initializeApp はアプリケーションの依存関係を初期化し、CLIインターフェースを返す
-func initializeApp() (*presentation.CLI, error) {
+// newVTT2MP3Service はGoogle Cloud Text-to-Speechサービスを使用するアプリケーションサービスを作成する
+func newVTT2MP3Service() (*application.VTT2MP3Service, error) {
 	// Google Cloud Text-to-Speechサービスの作成
 	ttsService, err := google.NewTextToSpeechService()
 	if err != nil {
@@ -35,23 +38,26 @@ func initializeApp() (*presentation.CLI, error) {
 	}
 
 	// アプリケーションサービスの作成
-	vtt2mp3Service := application.NewVTT2MP3Service(ttsService)
-
-	// CLIの作成
-	cli := presentation.NewCLI(vtt2mp3Service)
+	return application.NewVTT2MP3Service(ttsService), nil
+}
 
-	return cli, nil
+// initializeApp はアプリケーションの依存関係を初期化し、CLIインターフェースを返す
+// TTSサービスは音声合成が必要なサブコマンドの実行時に作成する
+func initializeApp() *presentation.CLI {
+	return presentation.NewCLI(newVTT2MP3Service)
 }
 
 func main() {
 	// アプリケーションの初期化
-	cli, err := initializeApp()
-	if err != nil {
-		handleError(err)
-	}
+	cli := initializeApp()
+
+	// Ctrl-Cまたは終了シグナルで実行中の処理を中断し、一時ファイルを削除してから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 
 	// CLIの実行
-	if err := cli.Run(os.Args[1:]); err != nil {
+	err := cli.Run(ctx, os.Args[1:])
+	stop()
+	if err != nil {
 		handleError(err)
 	}
 }
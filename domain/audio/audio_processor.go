@@ -2,6 +2,7 @@ package audio
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -38,20 +39,24 @@ func (p *AudioProcessor) CreateTempDir() (string, error) {
 // CleanupTempDir は一時ディレクトリを削除します
 func (p *AudioProcessor) CleanupTempDir(tempDir string) {
 	if err := os.RemoveAll(tempDir); err != nil {
-		fmt.Printf("警告: 一時ディレクトリ %s の削除に失敗しました: %v\n", tempDir, err)
+		fmt.Fprintf(os.Stderr, "警告: 一時ディレクトリ %s の削除に失敗しました: %v\n", tempDir, err)
 	}
 }
 
 // GetAudioDuration はffmpegを使用して音声ファイルの長さを取得します
-func (p *AudioProcessor) GetAudioDuration(audioFile string) (time.Duration, error) {
+// コンテキストが取り消された場合はffmpegを終了します
+func (p *AudioProcessor) GetAudioDuration(ctx context.Context, audioFile string) (time.Duration, error) {
 	// ffmpegコマンドを実行して長さ情報を取得
-	cmd := exec.Command("ffmpeg", "-i", audioFile, "-f", "null", "-")
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-i", audioFile, "-f", "null", "-")
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	// ここではエラーが発生することを想定しています（ffmpegは-f nullを使用すると終了コードでエラーを返す）
 	// 長さ情報を含む標準エラー出力のみを使用します
 	_ = cmd.Run() // 意図的にエラーを無視
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 
 	// 出力から長さを抽出
 	output := stderr.String()
@@ -76,68 +81,91 @@ func (p *AudioProcessor) GetAudioDuration(audioFile string) (time.Duration, erro
 }
 
 // MixAudioFilesWithTiming は正確なタイミングでffmpegを使用して全ての音声ファイルを結合します
-func (p *AudioProcessor) MixAudioFilesWithTiming(audioFiles []string, startTimes []time.Duration, output io.Writer) error {
-	if len(audioFiles) == 0 {
-		return fmt.Errorf("結合する音声ファイルがありません")
+// 重なりは調整せず、そのまま重ねて再生します
+func (p *AudioProcessor) MixAudioFilesWithTiming(ctx context.Context, audioFiles []string, startTimes []time.Duration, output io.Writer) error {
+	clips, err := p.LoadClips(ctx, audioFiles, startTimes)
+	if err != nil {
+		return err
 	}
 
+	return p.MixClips(ctx, clips, output)
+}
+
+// LoadClips は音声ファイルの長さを取得し、開始時間と組み合わせたクリップを作成します
+func (p *AudioProcessor) LoadClips(ctx context.Context, audioFiles []string, startTimes []time.Duration) ([]Clip, error) {
 	if len(audioFiles) != len(startTimes) {
-		return fmt.Errorf("音声ファイル数(%d)が開始時間の数(%d)と一致しません", len(audioFiles), len(startTimes))
+		return nil, fmt.Errorf("音声ファイル数(%d)が開始時間の数(%d)と一致しません", len(audioFiles), len(startTimes))
 	}
 
-	// 出力に必要な合計長さを計算
-	var maxEndTime time.Duration
+	clips := make([]Clip, len(audioFiles))
 	for i, audioFile := range audioFiles {
 		// 音声ファイルの実際の長さを取得
-		duration, err := p.GetAudioDuration(audioFile)
+		duration, err := p.GetAudioDuration(ctx, audioFile)
 		if err != nil {
-			return fmt.Errorf("音声ファイル %s の長さの取得に失敗しました: %v", audioFile, err)
+			return nil, fmt.Errorf("音声ファイル %s の長さの取得に失敗しました: %w", audioFile, err)
 		}
 
-		// 実際の長さを使用して終了時間を計算
-		endTime := startTimes[i] + duration
-		if endTime > maxEndTime {
-			maxEndTime = endTime
+		clips[i] = Clip{
+			File:     audioFile,
+			Start:    startTimes[i],
+			Duration: duration,
 		}
 	}
 
+	return clips, nil
+}
+
+// MixClips は各クリップの再生速度と打ち切りを適用し、正確なタイミングでffmpegを使用して結合します
+// 打ち切りで再生時間が0になったクリップは結合しません
+// コンテキストが取り消された場合はffmpegを終了します
+func (p *AudioProcessor) MixClips(ctx context.Context, clips []Clip, output io.Writer) error {
+	// 打ち切りで再生時間が0になったクリップはffmpegで扱えないため除く
+	clips = audibleClips(clips)
+	if len(clips) == 0 {
+		return fmt.Errorf("結合する音声ファイルがありません")
+	}
+
 	// 正確なタイミングのための複合フィルターを作成
 	filterComplex := ""
 
 	// フィルター複合部分を作成
-	for i, startTime := range startTimes {
+	for i, clip := range clips {
 		// 開始時間をミリ秒に変換（adelayフィルター用）
-		delayMs := startTime.Milliseconds()
+		delayMs := clip.Start.Milliseconds()
 
-		// 正確な遅延を持つ音声を追加
+		// 再生速度と打ち切りを適用してから正確な遅延を持つ音声を追加
 		// adelay=delays:all=1 は遅延を全チャンネルに適用することを意味します
-		filterComplex += fmt.Sprintf("[%d]adelay=%d:all=1[a%d]; ", i, delayMs, i)
+		filters := fmt.Sprintf("adelay=%d:all=1", delayMs)
+		if filter := clip.filter(); filter != "" {
+			filters = filter + "," + filters
+		}
+		filterComplex += fmt.Sprintf("[%d]%s[a%d]; ", i, filters, i)
 	}
 
 	// 全ての遅延された音声ストリームを結合
-	if len(audioFiles) == 1 {
+	if len(clips) == 1 {
 		// 音声ファイルが1つのみの場合、直接出力にマッピング
 		filterComplex += "[a0]aformat=sample_fmts=fltp:sample_rates=44100:channel_layouts=stereo[aout]"
 	} else {
 		// 複数の音声ファイルの場合、結合チェーンを作成
 		filterComplex += "[a0][a1]amix=inputs=2:dropout_transition=0:normalize=0[tmp0]; "
 
-		for i := 2; i < len(audioFiles); i++ {
+		for i := 2; i < len(clips); i++ {
 			filterComplex += fmt.Sprintf("[tmp%d][a%d]amix=inputs=2:dropout_transition=0:normalize=0[tmp%d]; ",
 				i-2, i, i-1)
 		}
 
 		// 最後のtmpをaoutにマッピング
 		filterComplex += fmt.Sprintf("[tmp%d]aformat=sample_fmts=fltp:sample_rates=44100:channel_layouts=stereo[aout]",
-			len(audioFiles)-2)
+			len(clips)-2)
 	}
 
 	// ffmpegコマンドを構築
-	cmd := exec.Command("ffmpeg", "-y")
+	cmd := exec.CommandContext(ctx, "ffmpeg", "-y")
 
 	// 全ての入力ファイルを追加
-	for _, audioFile := range audioFiles {
-		cmd.Args = append(cmd.Args, "-i", audioFile)
+	for _, clip := range clips {
+		cmd.Args = append(cmd.Args, "-i", clip.File)
 	}
 
 	// フィルター複合と出力オプションを追加
@@ -154,6 +182,9 @@ func (p *AudioProcessor) MixAudioFilesWithTiming(audioFiles []string, startTimes
 	cmd.Stderr = os.Stderr // デバッグ用
 
 	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		return fmt.Errorf("タイミング付きの音声ファイルの結合に失敗しました: %v", err)
 	}
 
@@ -0,0 +1,258 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 重なりの調整のデフォルト値
+const (
+	defaultMaxSpeedup   = 2.0
+	defaultFadeDuration = 100 * time.Millisecond
+)
+
+// OverlapPolicy は音声クリップの再生時間が次のクリップの開始時間と重なる場合の扱いを表します
+type OverlapPolicy int
+
+const (
+	// OverlapAllow は重なりをそのままにして音声を重ねて再生します
+	OverlapAllow OverlapPolicy = iota
+	// OverlapPush は後のクリップの開始を前のクリップの終了まで遅らせます
+	OverlapPush
+	// OverlapSpeedup は前のクリップを速く再生して次のクリップの開始までに収めます
+	OverlapSpeedup
+	// OverlapTruncate は前のクリップを次のクリップの開始で打ち切り、フェードアウトします
+	OverlapTruncate
+)
+
+// String は重なりの扱いを文字列に変換します
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapAllow:
+		return "allow"
+	case OverlapPush:
+		return "push"
+	case OverlapSpeedup:
+		return "speedup"
+	case OverlapTruncate:
+		return "truncate"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText は重なりの扱いをJSONなどのテキスト形式に変換します
+func (p OverlapPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// ParseOverlapPolicy は名前（allow, push, speedup, truncate）から重なりの扱いを返します
+func ParseOverlapPolicy(name string) (OverlapPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "allow", "":
+		return OverlapAllow, nil
+	case "push":
+		return OverlapPush, nil
+	case "speedup":
+		return OverlapSpeedup, nil
+	case "truncate":
+		return OverlapTruncate, nil
+	default:
+		return OverlapAllow, fmt.Errorf("不明な重なりの扱いです（allow, push, speedup, truncate）: %s", name)
+	}
+}
+
+// OverlapOptions は重なりの調整の設定を表します
+type OverlapOptions struct {
+	Policy       OverlapPolicy
+	MaxSpeedup   float64       // OverlapSpeedupで許容する最大の再生速度（0の場合は2.0、1の場合は速くしない）
+	FadeDuration time.Duration // OverlapTruncateで打ち切る際のフェードアウトの長さ（0の場合は100ミリ秒）
+}
+
+// Clip はタイミング付きで結合する音声クリップを表します
+type Clip struct {
+	File     string        // 音声ファイルのパス
+	Start    time.Duration // 再生を開始する時間
+	Duration time.Duration // 調整後の再生時間
+	Tempo    float64       // 再生速度（0または1の場合は等速）
+	Truncate bool          // Durationで打ち切ってフェードアウトするかどうか
+	Fade     time.Duration // 打ち切る際のフェードアウトの長さ
+}
+
+// Adjustment は重なりのために変更したクリップのタイミングを表します
+// 編集者がタイミングを妥協した箇所を確認するためのレポートに使用します
+type Adjustment struct {
+	Index            int           // クリップの番号（0始まり）
+	CueID            string        // クリップの元になった字幕のキュー識別子
+	Policy           OverlapPolicy // 適用した重なりの扱い
+	Action           string        // 実施した調整（overlap, push, speedup, truncate）
+	OriginalStart    time.Duration // 調整前の開始時間
+	Start            time.Duration // 調整後の開始時間
+	OriginalDuration time.Duration // 調整前の再生時間
+	Duration         time.Duration // 調整後の再生時間
+	Tempo            float64       // 調整後の再生速度
+	Overlap          time.Duration // 調整後も残る次のクリップとの重なり
+}
+
+// MarshalJSON は時間を秒単位の数値としてJSONに変換します
+func (a Adjustment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index            int           `json:"index"`
+		CueID            string        `json:"cue_id,omitempty"`
+		Policy           OverlapPolicy `json:"policy"`
+		Action           string        `json:"action"`
+		OriginalStart    float64       `json:"original_start"`
+		Start            float64       `json:"start"`
+		OriginalDuration float64       `json:"original_duration"`
+		Duration         float64       `json:"duration"`
+		Tempo            float64       `json:"tempo,omitempty"`
+		Overlap          float64       `json:"overlap,omitempty"`
+	}{
+		Index:            a.Index,
+		CueID:            a.CueID,
+		Policy:           a.Policy,
+		Action:           a.Action,
+		OriginalStart:    a.OriginalStart.Seconds(),
+		Start:            a.Start.Seconds(),
+		OriginalDuration: a.OriginalDuration.Seconds(),
+		Duration:         a.Duration.Seconds(),
+		Tempo:            a.Tempo,
+		Overlap:          a.Overlap.Seconds(),
+	})
+}
+
+// ResolveOverlaps は開始時間順に並べたクリップの重なりを指定された扱いで調整します
+// クリップの順序は変更せず、調整したクリップと調整内容を返します
+func ResolveOverlaps(clips []Clip, options OverlapOptions) ([]Clip, []Adjustment) {
+	if options.MaxSpeedup == 0 {
+		options.MaxSpeedup = defaultMaxSpeedup
+	}
+	// 1未満の倍率では遅く再生することになるため、等速を下限とする
+	options.MaxSpeedup = max(options.MaxSpeedup, 1)
+	if options.FadeDuration <= 0 {
+		options.FadeDuration = defaultFadeDuration
+	}
+
+	resolved := make([]Clip, len(clips))
+	copy(resolved, clips)
+
+	// 開始時間順に処理する（開始時間が同じ場合は元の順序）
+	order := make([]int, len(resolved))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return resolved[order[a]].Start < resolved[order[b]].Start
+	})
+
+	adjustments := []Adjustment{}
+	for position := 0; position+1 < len(order); position++ {
+		clip := &resolved[order[position]]
+		next := &resolved[order[position+1]]
+
+		overlap := clip.Start + clip.Duration - next.Start
+		if overlap <= 0 {
+			continue
+		}
+
+		adjustment := Adjustment{
+			Index:            order[position],
+			Policy:           options.Policy,
+			Action:           options.Policy.String(),
+			OriginalStart:    clips[order[position]].Start,
+			OriginalDuration: clips[order[position]].Duration,
+		}
+
+		switch options.Policy {
+		case OverlapPush:
+			// 後のクリップを遅らせる（後続のクリップにも順に影響する）
+			adjustment.Index = order[position+1]
+			adjustment.OriginalStart = clips[order[position+1]].Start
+			adjustment.OriginalDuration = clips[order[position+1]].Duration
+			next.Start += overlap
+			clip = next
+
+		case OverlapSpeedup:
+			available := next.Start - clip.Start
+			tempo := options.MaxSpeedup
+			if available > 0 {
+				tempo = min(float64(clip.Duration)/float64(available), options.MaxSpeedup)
+			}
+			*clip = clip.WithTempo(tempo)
+			adjustment.Overlap = max(clip.Start+clip.Duration-next.Start, 0)
+
+		case OverlapTruncate:
+			clip.Duration = max(next.Start-clip.Start, 0)
+			clip.Truncate = true
+			clip.Fade = min(options.FadeDuration, clip.Duration)
+
+		default:
+			adjustment.Action = "overlap"
+			adjustment.Overlap = overlap
+		}
+
+		adjustment.Start = clip.Start
+		adjustment.Duration = clip.Duration
+		adjustment.Tempo = clip.Tempo
+		adjustments = append(adjustments, adjustment)
+	}
+
+	return resolved, adjustments
+}
+
+// WithTempo は再生速度をさらに指定した倍率にしたクリップを返します
+// 既に再生速度が指定されている場合は倍率を掛け合わせ、再生時間も合わせて短くします
+func (c Clip) WithTempo(tempo float64) Clip {
+	if tempo <= 0 || tempo == 1 {
+		return c
+	}
+	current := c.Tempo
+	if current <= 0 {
+		current = 1
+	}
+	c.Tempo = current * tempo
+	c.Duration = time.Duration(float64(c.Duration) / tempo)
+	return c
+}
+
+// audibleClips は打ち切りで再生時間が0になったクリップを除いたクリップを返します
+func audibleClips(clips []Clip) []Clip {
+	audible := make([]Clip, 0, len(clips))
+	for _, clip := range clips {
+		if clip.Truncate && clip.Duration <= 0 {
+			continue
+		}
+		audible = append(audible, clip)
+	}
+	return audible
+}
+
+// filter はクリップの再生速度と打ち切りを行うffmpegのフィルターを返します
+func (c Clip) filter() string {
+	var filters []string
+
+	// atempoは1回あたり0.5倍から2倍までのため、それを超える場合は繰り返し適用する
+	if tempo := c.Tempo; tempo > 0 && tempo != 1 {
+		for tempo > 2 {
+			filters = append(filters, "atempo=2.0")
+			tempo /= 2
+		}
+		for tempo < 0.5 {
+			filters = append(filters, "atempo=0.5")
+			tempo /= 0.5
+		}
+		filters = append(filters, fmt.Sprintf("atempo=%.6f", tempo))
+	}
+
+	if c.Truncate {
+		filters = append(filters, fmt.Sprintf("atrim=0:%.3f", c.Duration.Seconds()))
+		if c.Fade > 0 {
+			filters = append(filters, fmt.Sprintf("afade=t=out:st=%.3f:d=%.3f", (c.Duration-c.Fade).Seconds(), c.Fade.Seconds()))
+		}
+	}
+
+	return strings.Join(filters, ",")
+}
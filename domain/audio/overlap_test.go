@@ -0,0 +1,135 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveOverlaps(t *testing.T) {
+	clips := []Clip{
+		{File: "a.mp3", Start: 0, Duration: 3 * time.Second},
+		{File: "b.mp3", Start: 2 * time.Second, Duration: time.Second},
+	}
+
+	tests := []struct {
+		name        string
+		options     OverlapOptions
+		wantStarts  []time.Duration
+		wantFirst   Clip
+		wantAction  string
+		wantOverlap time.Duration
+	}{
+		{
+			name:        "allow",
+			options:     OverlapOptions{Policy: OverlapAllow},
+			wantStarts:  []time.Duration{0, 2 * time.Second},
+			wantFirst:   clips[0],
+			wantAction:  "overlap",
+			wantOverlap: time.Second,
+		},
+		{
+			name:       "push",
+			options:    OverlapOptions{Policy: OverlapPush},
+			wantStarts: []time.Duration{0, 3 * time.Second},
+			wantFirst:  clips[0],
+			wantAction: "push",
+		},
+		{
+			name:       "speedup within limit",
+			options:    OverlapOptions{Policy: OverlapSpeedup},
+			wantStarts: []time.Duration{0, 2 * time.Second},
+			wantFirst:  Clip{File: "a.mp3", Duration: 2 * time.Second, Tempo: 1.5},
+			wantAction: "speedup",
+		},
+		{
+			name:        "speedup capped",
+			options:     OverlapOptions{Policy: OverlapSpeedup, MaxSpeedup: 1.2},
+			wantStarts:  []time.Duration{0, 2 * time.Second},
+			wantFirst:   Clip{File: "a.mp3", Duration: 2500 * time.Millisecond, Tempo: 1.2},
+			wantAction:  "speedup",
+			wantOverlap: 500 * time.Millisecond,
+		},
+		{
+			name:        "speedup limit of one keeps tempo",
+			options:     OverlapOptions{Policy: OverlapSpeedup, MaxSpeedup: 1},
+			wantStarts:  []time.Duration{0, 2 * time.Second},
+			wantFirst:   clips[0],
+			wantAction:  "speedup",
+			wantOverlap: time.Second,
+		},
+		{
+			name:       "truncate",
+			options:    OverlapOptions{Policy: OverlapTruncate},
+			wantStarts: []time.Duration{0, 2 * time.Second},
+			wantFirst:  Clip{File: "a.mp3", Duration: 2 * time.Second, Truncate: true, Fade: 100 * time.Millisecond},
+			wantAction: "truncate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, adjustments := ResolveOverlaps(clips, tt.options)
+
+			for i, want := range tt.wantStarts {
+				if resolved[i].Start != want {
+					t.Errorf("clip %d start = %v, want %v", i, resolved[i].Start, want)
+				}
+			}
+			first := resolved[0]
+			if first.Duration.Round(time.Millisecond) != tt.wantFirst.Duration ||
+				first.Truncate != tt.wantFirst.Truncate || first.Fade != tt.wantFirst.Fade ||
+				first.Tempo != tt.wantFirst.Tempo {
+				t.Errorf("first clip = %+v, want %+v", first, tt.wantFirst)
+			}
+
+			if len(adjustments) != 1 {
+				t.Fatalf("got %d adjustments, want 1", len(adjustments))
+			}
+			if adjustments[0].Action != tt.wantAction {
+				t.Errorf("action = %q, want %q", adjustments[0].Action, tt.wantAction)
+			}
+			if got := adjustments[0].Overlap.Round(time.Millisecond); got != tt.wantOverlap {
+				t.Errorf("overlap = %v, want %v", got, tt.wantOverlap)
+			}
+		})
+	}
+
+	// 元のクリップは変更しない
+	if clips[0].Duration != 3*time.Second || clips[1].Start != 2*time.Second {
+		t.Errorf("input clips were modified: %+v", clips)
+	}
+}
+
+func TestClipFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		clip Clip
+		want string
+	}{
+		{"plain", Clip{Duration: time.Second}, ""},
+		{"tempo", Clip{Tempo: 1.5}, "atempo=1.500000"},
+		{"tempo above two", Clip{Tempo: 3}, "atempo=2.0,atempo=1.500000"},
+		{"truncate with fade", Clip{Duration: 2 * time.Second, Truncate: true, Fade: 100 * time.Millisecond}, "atrim=0:2.000,afade=t=out:st=1.900:d=0.100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.clip.filter(); got != tt.want {
+				t.Errorf("filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudibleClips(t *testing.T) {
+	clips, _ := ResolveOverlaps([]Clip{
+		{File: "a.mp3", Start: time.Second, Duration: 2 * time.Second},
+		{File: "b.mp3", Start: time.Second, Duration: time.Second},
+		{File: "c.mp3", Start: 5 * time.Second, Duration: time.Second},
+	}, OverlapOptions{Policy: OverlapTruncate})
+
+	audible := audibleClips(clips)
+	if len(audible) != 2 || audible[0].File != "b.mp3" || audible[1].File != "c.mp3" {
+		t.Errorf("audibleClips() = %+v, want b.mp3 and c.mp3", audible)
+	}
+}
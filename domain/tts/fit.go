@@ -0,0 +1,125 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 表示時間に合わせる調整の設定
+const (
+	// defaultMaxFitSpeedup は許容する最大の速さの倍率のデフォルト値です
+	defaultMaxFitSpeedup = 1.5
+	// fitTolerance は音声が表示時間に収まったとみなす誤差（エンコーダーの無音の付加など）です
+	fitTolerance = 50 * time.Millisecond
+)
+
+// FitMode は合成した音声が字幕の表示時間に収まらない場合の調整方法を表します
+type FitMode int
+
+const (
+	// FitNone は音声の長さを調整しません
+	FitNone FitMode = iota
+	// FitRate は話す速さを上げて合成し直します
+	FitRate
+	// FitStretch は声の高さを保ったまま音声の再生速度を上げます
+	FitStretch
+)
+
+// String は調整方法を文字列に変換します
+func (m FitMode) String() string {
+	switch m {
+	case FitNone:
+		return "none"
+	case FitRate:
+		return "rate"
+	case FitStretch:
+		return "stretch"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText は調整方法をJSONなどのテキスト形式に変換します
+func (m FitMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// ParseFitMode は名前（none, rate, stretch）から調整方法を返します
+func ParseFitMode(name string) (FitMode, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "none", "":
+		return FitNone, nil
+	case "rate":
+		return FitRate, nil
+	case "stretch":
+		return FitStretch, nil
+	default:
+		return FitNone, fmt.Errorf("不明な表示時間への調整方法です（none, rate, stretch）: %s", name)
+	}
+}
+
+// FitOptions は合成した音声を字幕の表示時間に合わせる設定を表します
+type FitOptions struct {
+	Mode       FitMode
+	MaxSpeedup float64 // 元の速さに対して許容する最大の倍率（0の場合は1.5、1の場合は速くしない）
+}
+
+// Speedup は音声を表示時間に収めるために必要な速さの倍率を、許容する最大の倍率までの範囲で返します
+// 音声が表示時間に収まっている場合は1を返します
+func (o FitOptions) Speedup(duration, slot time.Duration) float64 {
+	if slot <= 0 || o.Fits(duration, slot) {
+		return 1
+	}
+	return min(float64(duration)/float64(slot), o.SpeedupLimit())
+}
+
+// Fits は音声が表示時間に収まっているかどうかを判定します
+func (o FitOptions) Fits(duration, slot time.Duration) bool {
+	return duration <= slot+fitTolerance
+}
+
+// SpeedupLimit は許容する最大の速さの倍率を返します
+// 1未満の倍率では遅くすることになるため、等速を下限とします
+func (o FitOptions) SpeedupLimit() float64 {
+	if o.MaxSpeedup == 0 {
+		return defaultMaxFitSpeedup
+	}
+	return max(o.MaxSpeedup, 1)
+}
+
+// FitResult は字幕の表示時間に合わせて音声を調整した結果を表します
+type FitResult struct {
+	Index            int           // 元になった字幕の番号（0始まり）
+	CueID            string        // リクエストの元になった字幕のキュー識別子
+	Mode             FitMode       // 適用した調整方法
+	Slot             time.Duration // 字幕の表示時間
+	OriginalDuration time.Duration // 調整前の音声の長さ
+	Duration         time.Duration // 調整後の音声の長さ
+	Speedup          float64       // 適用した速さの倍率
+	Fits             bool          // 調整後の音声が表示時間に収まったかどうか
+}
+
+// MarshalJSON は時間を秒単位の数値としてJSONに変換します
+func (r FitResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Index            int     `json:"index"`
+		CueID            string  `json:"cue_id,omitempty"`
+		Mode             FitMode `json:"mode"`
+		Slot             float64 `json:"slot"`
+		OriginalDuration float64 `json:"original_duration"`
+		Duration         float64 `json:"duration"`
+		Speedup          float64 `json:"speedup"`
+		Fits             bool    `json:"fits"`
+	}{
+		Index:            r.Index,
+		CueID:            r.CueID,
+		Mode:             r.Mode,
+		Slot:             r.Slot.Seconds(),
+		OriginalDuration: r.OriginalDuration.Seconds(),
+		Duration:         r.Duration.Seconds(),
+		Speedup:          r.Speedup,
+		Fits:             r.Fits,
+	})
+}
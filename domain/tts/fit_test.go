@@ -0,0 +1,75 @@
+package tts
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFitOptionsSpeedup(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  FitOptions
+		duration time.Duration
+		slot     time.Duration
+		want     float64
+	}{
+		{"fits", FitOptions{}, 2 * time.Second, 2 * time.Second, 1},
+		{"within tolerance", FitOptions{}, 2040 * time.Millisecond, 2 * time.Second, 1},
+		{"no slot", FitOptions{}, 2 * time.Second, 0, 1},
+		{"needs speedup", FitOptions{}, 3 * time.Second, 2500 * time.Millisecond, 1.2},
+		{"default limit", FitOptions{}, 4 * time.Second, 2 * time.Second, 1.5},
+		{"custom limit", FitOptions{MaxSpeedup: 1.8}, 4 * time.Second, 2 * time.Second, 1.8},
+		{"limit of one", FitOptions{MaxSpeedup: 1}, 4 * time.Second, 2 * time.Second, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.options.Speedup(tt.duration, tt.slot); got != tt.want {
+				t.Errorf("Speedup(%v, %v) = %v, want %v", tt.duration, tt.slot, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitOptionsSpeedupLimit(t *testing.T) {
+	tests := []struct {
+		maxSpeedup float64
+		want       float64
+	}{
+		{0, 1.5},
+		{1, 1},
+		{0.5, 1},
+		{2, 2},
+	}
+
+	for _, tt := range tests {
+		if got := (FitOptions{MaxSpeedup: tt.maxSpeedup}).SpeedupLimit(); got != tt.want {
+			t.Errorf("SpeedupLimit() with MaxSpeedup %v = %v, want %v", tt.maxSpeedup, got, tt.want)
+		}
+	}
+}
+
+func TestParseFitMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    FitMode
+		wantErr bool
+	}{
+		{"", FitNone, false},
+		{"none", FitNone, false},
+		{"Rate", FitRate, false},
+		{" stretch ", FitStretch, false},
+		{"fast", FitNone, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFitMode(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFitMode(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseFitMode(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
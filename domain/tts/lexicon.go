@@ -0,0 +1,353 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// 発音記号の種類
+const (
+	AlphabetIPA    = "ipa"
+	AlphabetXSAMPA = "x-sampa"
+)
+
+// allLanguages はすべての言語に適用する辞書のセクション名です
+const allLanguages = "*"
+
+// LexiconEntry は発音辞書の1つの語の読み方を表します
+type LexiconEntry struct {
+	Term          string // 対象の語
+	Replacement   string // 読み上げに使用するテキスト（Phonemeと同時には指定しない）
+	Phoneme       string // 発音記号
+	Alphabet      string // 発音記号の種類（ipa または x-sampa）
+	CaseSensitive bool   // 大文字と小文字を区別するかどうか
+}
+
+// LexiconSegment は発音辞書を適用したテキストの一部を表します
+type LexiconSegment struct {
+	Text     string // 読み上げるテキスト（置換済み）
+	Phoneme  string // 発音記号（空の場合はTextをそのまま読み上げる）
+	Alphabet string // 発音記号の種類
+}
+
+// Lexicon は言語ごとの発音辞書を表します
+// 複数のゴルーチンから同時にSplitやApplyを呼び出すことができます
+type Lexicon struct {
+	sections map[string][]LexiconEntry
+
+	mu       sync.Mutex
+	matchers map[string]*lexiconMatcher // 言語ごとの正規表現のキャッシュ
+}
+
+// lexiconMatcher は言語に適用する語と、そのいずれかに一致する正規表現を表します
+// 正規表現のi番目のグループはentriesのi番目の語に対応します
+type lexiconMatcher struct {
+	pattern *regexp.Regexp
+	entries []LexiconEntry
+}
+
+// NewLexicon は言語コード（"ja", "en-US"、すべての言語の場合は"*"）ごとの語の一覧から発音辞書を作成します
+func NewLexicon(sections map[string][]LexiconEntry) (*Lexicon, error) {
+	lexicon := &Lexicon{sections: make(map[string][]LexiconEntry)}
+	for language, entries := range sections {
+		for _, entry := range entries {
+			if err := lexicon.add(language, entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return lexicon, nil
+}
+
+// add は語を辞書に追加します
+func (l *Lexicon) add(language string, entry LexiconEntry) error {
+	if entry.Term == "" {
+		return fmt.Errorf("発音辞書の語が空です（%s）", language)
+	}
+	if entry.Phoneme != "" {
+		if entry.Replacement != "" {
+			return fmt.Errorf("語 %s に置換テキストと発音記号の両方が指定されています", entry.Term)
+		}
+		entry.Alphabet = strings.ToLower(entry.Alphabet)
+		if entry.Alphabet == "" {
+			entry.Alphabet = AlphabetIPA
+		}
+		if entry.Alphabet != AlphabetIPA && entry.Alphabet != AlphabetXSAMPA {
+			return fmt.Errorf("語 %s の発音記号の種類が不正です（ipa または x-sampa）: %s", entry.Term, entry.Alphabet)
+		}
+	}
+
+	key := normalizeLanguageKey(language)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sections[key] = append(l.sections[key], entry)
+	l.matchers = nil
+	return nil
+}
+
+// Merge は他の発音辞書の語を追加します。同じ語がある場合は後から追加した辞書の読み方を優先します
+func (l *Lexicon) Merge(other *Lexicon) {
+	if other == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for language, entries := range other.sections {
+		l.sections[language] = append(l.sections[language], entries...)
+	}
+	l.matchers = nil
+}
+
+// entriesFor は言語に適用する語の一覧を、優先度の高い順（言語と地域、言語、すべての言語）に返します
+// 同じ語が複数ある場合は優先度の高いものだけを残します
+func (l *Lexicon) entriesFor(languageCode string) []LexiconEntry {
+	key := normalizeLanguageKey(languageCode)
+	keys := []string{key}
+	if base, _, found := strings.Cut(key, "-"); found {
+		keys = append(keys, base)
+	}
+	keys = append(keys, allLanguages)
+
+	seen := make(map[string]bool)
+	var entries []LexiconEntry
+	for _, key := range keys {
+		section := l.sections[key]
+		// 同じセクション内では後から追加した語を優先する
+		for i := len(section) - 1; i >= 0; i-- {
+			entry := section[i]
+			term := entry.Term
+			if !entry.CaseSensitive {
+				term = strings.ToLower(term)
+			}
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// matcher は言語に適用する語のいずれかに一致する正規表現を返します（語がない場合はnil）
+// 長い語を優先し、英数字で始まる（終わる）語は単語の途中には一致しないようにします
+// 作成した正規表現は言語ごとにキャッシュし、辞書に語を追加するまで再利用します
+func (l *Lexicon) matcher(languageCode string) *lexiconMatcher {
+	key := normalizeLanguageKey(languageCode)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if matcher, ok := l.matchers[key]; ok {
+		return matcher
+	}
+
+	entries := l.entriesFor(languageCode)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return utf8.RuneCountInString(entries[i].Term) > utf8.RuneCountInString(entries[j].Term)
+	})
+
+	var matcher *lexiconMatcher
+	if len(entries) > 0 {
+		alternatives := make([]string, len(entries))
+		for i, entry := range entries {
+			alternative := regexp.QuoteMeta(entry.Term)
+			if !entry.CaseSensitive {
+				alternative = "(?i:" + alternative + ")"
+			}
+			if first, _ := utf8.DecodeRuneInString(entry.Term); isASCIIWordRune(first) {
+				alternative = `\b` + alternative
+			}
+			if last, _ := utf8.DecodeLastRuneInString(entry.Term); isASCIIWordRune(last) {
+				alternative += `\b`
+			}
+			alternatives[i] = "(" + alternative + ")"
+		}
+		matcher = &lexiconMatcher{
+			pattern: regexp.MustCompile(strings.Join(alternatives, "|")),
+			entries: entries,
+		}
+	}
+
+	if l.matchers == nil {
+		l.matchers = make(map[string]*lexiconMatcher)
+	}
+	l.matchers[key] = matcher
+	return matcher
+}
+
+// Split はテキストに発音辞書を適用し、通常のテキストと発音記号を指定した語に分割します
+// 置換テキストが指定された語は置換した上で通常のテキストとして返します
+func (l *Lexicon) Split(text, languageCode string) []LexiconSegment {
+	if l == nil || text == "" {
+		return []LexiconSegment{{Text: text}}
+	}
+
+	matcher := l.matcher(languageCode)
+	if matcher == nil {
+		return []LexiconSegment{{Text: text}}
+	}
+	entries := matcher.entries
+
+	var segments []LexiconSegment
+	var plain strings.Builder
+	last := 0
+	for _, match := range matcher.pattern.FindAllStringSubmatchIndex(text, -1) {
+		plain.WriteString(text[last:match[0]])
+		last = match[1]
+
+		// どの語に一致したかを部分一致の位置から判定する
+		entry := entries[0]
+		for i := range entries {
+			if match[2+i*2] >= 0 {
+				entry = entries[i]
+				break
+			}
+		}
+
+		if entry.Phoneme == "" {
+			plain.WriteString(entry.Replacement)
+			continue
+		}
+		if plain.Len() > 0 {
+			segments = append(segments, LexiconSegment{Text: plain.String()})
+			plain.Reset()
+		}
+		segments = append(segments, LexiconSegment{
+			Text:     text[match[0]:match[1]],
+			Phoneme:  entry.Phoneme,
+			Alphabet: entry.Alphabet,
+		})
+	}
+	plain.WriteString(text[last:])
+	if plain.Len() > 0 || len(segments) == 0 {
+		segments = append(segments, LexiconSegment{Text: plain.String()})
+	}
+
+	return segments
+}
+
+// Apply はリクエストのテキストに発音辞書を適用します
+// 置換テキストはテキストを書き換え、発音記号を指定した語がある場合はSSMLの<phoneme>として入力に設定します
+func (l *Lexicon) Apply(request *TextToSpeechRequest) {
+	if l == nil || request.Input.SSML != "" {
+		return
+	}
+
+	segments := l.Split(request.Input.Text, request.Voice.LanguageCode)
+
+	var text, ssml strings.Builder
+	hasPhoneme := false
+	ssml.WriteString("<speak>")
+	for _, segment := range segments {
+		text.WriteString(segment.Text)
+		if segment.Phoneme == "" {
+			ssml.WriteString(EscapeSSML(segment.Text))
+			continue
+		}
+		hasPhoneme = true
+		writePhoneme(&ssml, segment)
+	}
+	ssml.WriteString("</speak>")
+
+	request.Input.Text = text.String()
+	if hasPhoneme {
+		request.Input.SSML = ssml.String()
+	}
+}
+
+// writePhoneme は発音記号を指定した語をSSMLの<phoneme>として書き込みます
+// 語に強調の目印が含まれる場合は取り除きます
+func writePhoneme(ssml *strings.Builder, segment LexiconSegment) {
+	fmt.Fprintf(ssml, `<phoneme alphabet="%s" ph="%s">%s</phoneme>`,
+		segment.Alphabet, EscapeSSML(segment.Phoneme), EscapeSSML(StripEmphasis(segment.Text)))
+}
+
+// EscapeSSML はテキストをSSMLの要素の内容や属性値として使用できるようにエスケープします
+func EscapeSSML(text string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+	return replacer.Replace(text)
+}
+
+// isASCIIWordRune は文字がASCIIの英数字またはアンダースコアかどうかを判定します
+func isASCIIWordRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// normalizeLanguageKey は言語コードを辞書のセクション名として比較できる形式にします
+func normalizeLanguageKey(languageCode string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(languageCode), "_", "-"))
+}
+
+// lexiconEntryJSON は発音辞書ファイル内の語の読み方を表します
+// 文字列の場合は置換テキスト、オブジェクトの場合は置換テキストまたは発音記号として解析します
+type lexiconEntryJSON struct {
+	Replacement   string `json:"replacement"`
+	Phoneme       string `json:"phoneme"`
+	Alphabet      string `json:"alphabet"`
+	CaseSensitive bool   `json:"case_sensitive"`
+}
+
+// UnmarshalJSON は文字列またはオブジェクトの読み方を解析します
+func (e *lexiconEntryJSON) UnmarshalJSON(data []byte) error {
+	var replacement string
+	if err := json.Unmarshal(data, &replacement); err == nil {
+		e.Replacement = replacement
+		return nil
+	}
+
+	type entry lexiconEntryJSON
+	return json.Unmarshal(data, (*entry)(e))
+}
+
+// LoadLexicon はJSON形式の発音辞書ファイルを読み込みます
+// 言語コードごと（"*"はすべての言語）に、語から置換テキストまたは発音記号への対応を記述します
+//
+//	{
+//	  "*":  {"GitHub": "ギットハブ"},
+//	  "ja": {"東海林": {"phoneme": "ʃoːdʑi", "alphabet": "ipa"}},
+//	  "en-US": {"SQL": "sequel", "Nguyen": {"phoneme": "w I n", "alphabet": "x-sampa"}}
+//	}
+func LoadLexicon(filePath string) (*Lexicon, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections map[string]map[string]lexiconEntryJSON
+	if err := json.Unmarshal(data, &sections); err != nil {
+		return nil, fmt.Errorf("発音辞書ファイル %s の解析に失敗しました: %w", filePath, err)
+	}
+
+	entries := make(map[string][]LexiconEntry, len(sections))
+	for language, terms := range sections {
+		// ファイル内の順序に依存しないよう、語の順に並べる
+		names := make([]string, 0, len(terms))
+		for term := range terms {
+			names = append(names, term)
+		}
+		sort.Strings(names)
+
+		for _, term := range names {
+			v := terms[term]
+			entries[language] = append(entries[language], LexiconEntry{
+				Term:          term,
+				Replacement:   v.Replacement,
+				Phoneme:       v.Phoneme,
+				Alphabet:      v.Alphabet,
+				CaseSensitive: v.CaseSensitive,
+			})
+		}
+	}
+
+	lexicon, err := NewLexicon(entries)
+	if err != nil {
+		return nil, fmt.Errorf("発音辞書ファイル %s の内容が不正です: %w", filePath, err)
+	}
+	return lexicon, nil
+}
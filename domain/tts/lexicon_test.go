@@ -0,0 +1,245 @@
+package tts
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testLexicon はテストで使用する発音辞書ファイルの内容です
+const testLexicon = `{
+	"*":     {"GitHub": "ギットハブ", "US": {"replacement": "United States", "case_sensitive": true}},
+	"en":    {"SQL": "sequel", "Nguyen": {"phoneme": "w I n", "alphabet": "X-SAMPA"}},
+	"en_US": {"SQL": "ess queue ell"},
+	"ja":    {"東海林": {"phoneme": "ʃoːdʑi"}}
+}`
+
+// loadTestLexicon は内容を一時ファイルに書き込んで発音辞書として読み込みます
+func loadTestLexicon(t *testing.T, content string) (*Lexicon, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "lexicon.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadLexicon(path)
+}
+
+func TestLoadLexiconErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"unknown alphabet", `{"en": {"x": {"phoneme": "a", "alphabet": "arpabet"}}}`},
+		{"replacement and phoneme", `{"en": {"x": {"phoneme": "a", "replacement": "b"}}}`},
+		{"empty term", `{"en": {"": "b"}}`},
+		{"invalid entry", `{"en": {"x": 1}}`},
+		{"invalid json", `{"en": ["x"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadTestLexicon(t, tt.content); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestLexiconSplit(t *testing.T) {
+	lexicon, err := loadTestLexicon(t, testLexicon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		text     string
+		want     []LexiconSegment
+	}{
+		{"region section first", "en-US", "SQL on GitHub", []LexiconSegment{{Text: "ess queue ell on ギットハブ"}}},
+		{"base language section", "en-GB", "SQL on GitHub", []LexiconSegment{{Text: "sequel on ギットハブ"}}},
+		{"all languages section only", "ja-JP", "SQL on GitHub", []LexiconSegment{{Text: "SQL on ギットハブ"}}},
+		{"case insensitive", "en-GB", "sql", []LexiconSegment{{Text: "sequel"}}},
+		{"case sensitive", "en-GB", "US and us", []LexiconSegment{{Text: "United States and us"}}},
+		{"word boundaries", "en-GB", "SQLite, MySQL, SQL_x and SQL.", []LexiconSegment{{Text: "SQLite, MySQL, SQL_x and sequel."}}},
+		{
+			name:     "phoneme",
+			language: "en-US",
+			text:     "Mr. Nguyen & SQL",
+			want: []LexiconSegment{
+				{Text: "Mr. "},
+				{Text: "Nguyen", Phoneme: "w I n", Alphabet: AlphabetXSAMPA},
+				{Text: " & ess queue ell"},
+			},
+		},
+		{
+			name:     "phoneme inside japanese text",
+			language: "ja-JP",
+			text:     "東海林さん",
+			want: []LexiconSegment{
+				{Text: "東海林", Phoneme: "ʃoːdʑi", Alphabet: AlphabetIPA},
+				{Text: "さん"},
+			},
+		},
+		{"no match", "fr-FR", "Bonjour", []LexiconSegment{{Text: "Bonjour"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lexicon.Split(tt.text, tt.language)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Split(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("Split(%q)[%d] = %+v, want %+v", tt.text, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLexiconMerge(t *testing.T) {
+	lexicon, err := NewLexicon(map[string][]LexiconEntry{"*": {{Term: "SQL", Replacement: "sequel"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 正規表現のキャッシュを作成してから語を追加する
+	if got := lexicon.Split("SQL", "en"); got[0].Text != "sequel" {
+		t.Fatalf("Split() = %+v, want sequel", got)
+	}
+
+	other, err := NewLexicon(map[string][]LexiconEntry{"*": {{Term: "sql", Replacement: "S Q L"}, {Term: "DB", Replacement: "database"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lexicon.Merge(other)
+
+	if got := lexicon.Split("SQL DB", "en"); len(got) != 1 || got[0].Text != "S Q L database" {
+		t.Errorf("Split() after Merge = %+v, want %q", got, "S Q L database")
+	}
+}
+
+func TestLexiconApply(t *testing.T) {
+	lexicon, err := loadTestLexicon(t, testLexicon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		input      SynthesisInput
+		wantText   string
+		wantSSML   string
+		nilLexicon bool
+	}{
+		{
+			name:     "replacement only",
+			input:    SynthesisInput{Text: "SQL on GitHub"},
+			wantText: "ess queue ell on ギットハブ",
+		},
+		{
+			name:     "phoneme",
+			input:    SynthesisInput{Text: "Mr. Nguyen & SQL"},
+			wantText: "Mr. Nguyen & ess queue ell",
+			wantSSML: `<speak>Mr. <phoneme alphabet="x-sampa" ph="w I n">Nguyen</phoneme> &amp; ess queue ell</speak>`,
+		},
+		{
+			name:     "existing SSML is kept",
+			input:    SynthesisInput{Text: "SQL", SSML: "<speak>SQL</speak>"},
+			wantText: "SQL",
+			wantSSML: "<speak>SQL</speak>",
+		},
+		{
+			name:       "nil lexicon",
+			input:      SynthesisInput{Text: "SQL"},
+			wantText:   "SQL",
+			nilLexicon: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := TextToSpeechRequest{Input: tt.input, Voice: VoiceSelectionParams{LanguageCode: "en-US"}}
+			target := lexicon
+			if tt.nilLexicon {
+				target = nil
+			}
+			target.Apply(&request)
+
+			if request.Input.Text != tt.wantText || request.Input.SSML != tt.wantSSML {
+				t.Errorf("Apply() = {%q %q}, want {%q %q}", request.Input.Text, request.Input.SSML, tt.wantText, tt.wantSSML)
+			}
+			if request.Input.SSML != "" {
+				if err := ValidateSSML(request.Input.SSML); err != nil {
+					t.Errorf("applied SSML is invalid: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateSSMLWithLexicon(t *testing.T) {
+	lexicon, err := loadTestLexicon(t, testLexicon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		language string
+		text     string
+		want     string
+	}{
+		{
+			name:     "replacement and phoneme",
+			language: "en-US",
+			text:     "SQL & Nguyen",
+			want:     `<speak>ess queue ell &amp; <phoneme alphabet="x-sampa" ph="w I n">Nguyen</phoneme></speak>`,
+		},
+		{
+			name:     "phoneme inside emphasis",
+			language: "en-US",
+			text:     "Mr. " + EmphasisStart + "Nguyen" + EmphasisEnd,
+			want:     `<speak>Mr. <emphasis level="moderate"><phoneme alphabet="x-sampa" ph="w I n">Nguyen</phoneme></emphasis></speak>`,
+		},
+		{
+			name:     "ipa",
+			language: "ja-JP",
+			text:     "東海林さん",
+			want:     `<speak><phoneme alphabet="ipa" ph="ʃoːdʑi">東海林</phoneme>さん</speak>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateSSML(tt.text, tt.language, lexicon)
+			if got != tt.want {
+				t.Errorf("GenerateSSML(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if err := ValidateSSML(got); err != nil {
+				t.Errorf("generated SSML is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestLexiconConcurrentSplit(t *testing.T) {
+	lexicon, err := loadTestLexicon(t, testLexicon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 言語ごとの正規表現のキャッシュを複数のゴルーチンから同時に作成する
+	var wg sync.WaitGroup
+	for _, language := range []string{"en-US", "en-GB", "ja-JP", "en-US", "en-GB", "ja-JP"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lexicon.Split("SQL on GitHub", language)
+		}()
+	}
+	wg.Wait()
+}
@@ -1,8 +1,13 @@
 package tts
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"strings"
 	"time"
+
+	"vtt2mp3/domain/audio"
 )
 
 // AudioFormat は音声出力のフォーマットを表します
@@ -53,10 +58,31 @@ func (g VoiceGender) String() string {
 	}
 }
 
+// MarshalText は性別をJSONなどのテキスト形式に変換します
+func (g VoiceGender) MarshalText() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+// ParseVoiceGender は文字列（"male", "female", "neutral"）をVoiceGenderに変換します
+func ParseVoiceGender(value string) (VoiceGender, error) {
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "MALE":
+		return Male, nil
+	case "FEMALE":
+		return Female, nil
+	case "NEUTRAL", "":
+		return Neutral, nil
+	default:
+		return Neutral, fmt.Errorf("不明な声の性別です: %s", value)
+	}
+}
+
 // SynthesisInput は音声合成の入力テキストを表します
 type SynthesisInput struct {
 	// Text は音声に変換するテキスト内容
 	Text string
+	// SSML は音声に変換するSSMLドキュメント（空でない場合はTextの代わりに使用します）
+	SSML string
 }
 
 // VoiceSelectionParams は音声選択パラメータを表します
@@ -65,12 +91,24 @@ type VoiceSelectionParams struct {
 	LanguageCode string
 	// Gender は声の性別
 	Gender VoiceGender
+	// Name は使用する声の名前（例: "ja-JP-Neural2-B"）。空の場合はプロバイダーが選択します
+	Name string
 }
 
 // AudioConfig は音声出力の設定を表します
 type AudioConfig struct {
 	// AudioFormat は音声のフォーマット（MP3, WAVなど）
 	AudioFormat AudioFormat
+	// SpeakingRate は話す速さ（1.0が標準、0はプロバイダーの既定値）
+	SpeakingRate float64
+	// Pitch は声の高さ（半音単位、0が標準）
+	Pitch float64
+	// VolumeGainDb は音量の増減（dB、0が標準）
+	VolumeGainDb float64
+	// SampleRateHertz は出力のサンプルレート（Hz、0は声の本来のサンプルレート）
+	SampleRateHertz int
+	// EffectsProfileIDs は再生機器に合わせて適用する効果（例: "headphone-class-device"）
+	EffectsProfileIDs []string
 }
 
 // TextToSpeechRequest はテキストから音声への変換リクエストを表します
@@ -83,13 +121,94 @@ type TextToSpeechRequest struct {
 	AudioConfig AudioConfig
 	// StartTime は複数テキストを合成する際の開始時間
 	StartTime time.Duration
+	// EndTime は字幕の表示が終わる時間（0の場合は表示時間に合わせる調整をしない）
+	EndTime time.Duration
+	// CueID はリクエストの元になった字幕のキュー識別子（空の場合あり）
+	CueID string
+	// CueNumber はリクエストの元になった字幕のファイル内の番号（1始まり、0の場合はリクエストの順序で数える）
+	CueNumber int
+}
+
+// CueIndex は元になった字幕の番号（0始まり）を返します
+// 字幕の番号が設定されていない場合は、指定されたリクエストの番号を返します
+func (r TextToSpeechRequest) CueIndex(requestIndex int) int {
+	if r.CueNumber > 0 {
+		return r.CueNumber - 1
+	}
+	return requestIndex
+}
+
+// Slot は字幕の表示時間を返します（終了時間がない場合は0）
+func (r TextToSpeechRequest) Slot() time.Duration {
+	if r.EndTime <= r.StartTime {
+		return 0
+	}
+	return r.EndTime - r.StartTime
+}
+
+// CueLabel はエラーメッセージやレポートで字幕を特定するためのラベルを返します
+// キュー識別子がある場合は識別子を、ない場合は1始まりの番号を使用します
+func CueLabel(index int, cueID string) string {
+	if cueID != "" {
+		return fmt.Sprintf("#%d (ID: %s)", index+1, cueID)
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// SynthesisOptions は複数のテキストを合成して結合する際の設定を表します
+type SynthesisOptions struct {
+	// Overlap は音声が次の字幕の開始時間と重なる場合の扱い
+	Overlap audio.OverlapOptions
+	// Fit は音声が字幕の表示時間に収まらない場合の調整
+	Fit FitOptions
+	// Workers は並行して合成するリクエストの数（0の場合はプロバイダーの既定値）
+	Workers int
+	// RequestsPerSecond は1秒あたりの合成リクエスト数の上限（0の場合はプロバイダーの既定値）
+	RequestsPerSecond float64
+	// Retry は一時的なエラーで合成に失敗した場合の再試行の設定
+	Retry RetryOptions
+}
+
+// SynthesisReport は複数のテキストの合成結果のレポートを表します
+type SynthesisReport struct {
+	// Adjustments は重なりのために変更したタイミングの一覧
+	Adjustments []audio.Adjustment `json:"adjustments"`
+	// Fits は字幕の表示時間に合わせて音声を調整した結果の一覧（収まらなかった字幕を含む）
+	Fits []FitResult `json:"fits"`
+}
+
+// Unfit は調整しても字幕の表示時間に収まらなかった結果を返します
+func (r *SynthesisReport) Unfit() []FitResult {
+	var unfit []FitResult
+	for _, result := range r.Fits {
+		if !result.Fits {
+			unfit = append(unfit, result)
+		}
+	}
+	return unfit
+}
+
+// Voice はプロバイダーが提供する声を表します
+type Voice struct {
+	// Name は声の名前（例: "ja-JP-Neural2-B"）
+	Name string `json:"name"`
+	// LanguageCodes は声が対応する言語コードの一覧
+	LanguageCodes []string `json:"language_codes"`
+	// Gender は声の性別
+	Gender VoiceGender `json:"gender"`
+	// NaturalSampleRateHertz は声の本来のサンプルレート（Hz）
+	NaturalSampleRateHertz int `json:"natural_sample_rate_hertz"`
 }
 
 // TextToSpeechService はテキスト読み上げサービスのインターフェースを定義します
+// コンテキストが取り消された場合は、実行中のリクエストと音声の処理を中断してエラーを返します
 type TextToSpeechService interface {
 	// SynthesizeSpeech はテキストを音声に変換し、音声コンテンツを返します
-	SynthesizeSpeech(request TextToSpeechRequest) ([]byte, error)
+	SynthesizeSpeech(ctx context.Context, request TextToSpeechRequest) ([]byte, error)
+
+	// SynthesizeMultiple は複数のテキストをタイミング情報付きで音声に変換し、タイミングの調整内容を返します
+	SynthesizeMultiple(ctx context.Context, requests []TextToSpeechRequest, output io.Writer, options SynthesisOptions) (*SynthesisReport, error)
 
-	// SynthesizeMultiple は複数のテキストをタイミング情報付きで音声に変換します
-	SynthesizeMultiple(requests []TextToSpeechRequest, output io.Writer) error
+	// ListVoices は使用できる声の一覧を返します（languageCodeが空の場合はすべての言語）
+	ListVoices(ctx context.Context, languageCode string) ([]Voice, error)
 }
@@ -0,0 +1,280 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// 組み込みの正規化処理の名前
+const (
+	NormalizeEntities          = "entities"
+	NormalizeSoundDescriptions = "sound-descriptions"
+	NormalizeSpeakerPrefixes   = "speaker-prefixes"
+	NormalizeMusicNotes        = "music-notes"
+	NormalizeURLs              = "urls"
+	NormalizeDialogueDashes    = "dialogue-dashes"
+	NormalizeWhitespace        = "whitespace"
+)
+
+// 正規化に使用するパターン
+var (
+	// bracketedRegex は角括弧で囲まれた効果音の説明（[MUSIC], [拍手]など）を表します
+	bracketedRegex = regexp.MustCompile(`[\[［][^\]］\n]*[\]］]`)
+	// parentheticalRegex は丸括弧で囲まれた説明（(laughs), （笑）など）を表します
+	parentheticalRegex = regexp.MustCompile(`[(（]([^)）\n]*)[)）]`)
+	// leadingParentheticalRegex は行頭の丸括弧で囲まれた話者名（（田中）など）を表します
+	leadingParentheticalRegex = regexp.MustCompile(`(?m)^[ \t]*[(（][^)）\n]{1,20}[)）][ \t]*`)
+	// speakerPrefixRegex は行頭の大文字の話者名（JOHN:, DR. SMITH:など）と">>"を表します
+	speakerPrefixRegex = regexp.MustCompile(`(?m)^[ \t]*(?:>>+[ \t]*)?(?:[A-Z][A-Z0-9 .'\-]{0,30}:[ \t]+)?`)
+	// musicNoteRegex は音符の記号を表します
+	musicNoteRegex = regexp.MustCompile(`[♪♫♬♩]+`)
+	// urlRegex はURLを表します
+	urlRegex = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'）」]+`)
+	// dialogueDashRegex は行頭の会話を区切るダッシュ（空白と数字以外の文字が続くもの）を表します
+	// "-5 degrees"のような負の数は残します
+	dialogueDashRegex = regexp.MustCompile(`(?m)^[ \t]*[-‐–—―][ \t]+([^\d\s])`)
+	// spacesRegex は連続する空白を表します
+	spacesRegex = regexp.MustCompile(`[ \t\x{3000}]+`)
+)
+
+// soundWords は丸括弧で囲まれている場合に効果音の説明とみなす語です（言語ごと）
+var soundWords = map[string][]string{
+	"en": {"laugh", "laughs", "laughing", "laughter", "chuckles", "giggles", "applause", "music", "sighs", "sigh",
+		"coughs", "cough", "cheering", "cheers", "gasps", "groans", "screams", "crying", "sobbing", "whispers",
+		"inaudible", "indistinct", "silence", "static", "beep", "beeping", "door closes", "door opens", "footsteps",
+		"phone rings", "clapping", "music playing", "upbeat music", "dramatic music", "background noise"},
+	"ja": {"笑", "笑い", "笑い声", "拍手", "音楽", "歓声", "ため息", "咳", "咳払い", "泣き声", "拍手喝采", "BGM", "効果音", "沈黙", "ざわめき"},
+}
+
+// Normalizer は音声合成の前にテキストを読み上げ用に整える処理を表します
+type Normalizer interface {
+	Normalize(text string) string
+}
+
+// NormalizerFunc は関数をNormalizerとして使用するための型です
+type NormalizerFunc func(text string) string
+
+// Normalize は関数を呼び出します
+func (f NormalizerFunc) Normalize(text string) string {
+	return f(text)
+}
+
+// NormalizerChain は複数の正規化処理を順に適用します
+type NormalizerChain []Normalizer
+
+// Normalize は正規化処理を順に適用し、前後の空白を取り除きます
+func (c NormalizerChain) Normalize(text string) string {
+	for _, normalizer := range c {
+		text = normalizer.Normalize(text)
+	}
+	return strings.TrimSpace(text)
+}
+
+// ReplaceRule は正規表現による置換を行うユーザー定義の正規化処理です
+type ReplaceRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Normalize はパターンに一致する部分を置換します（置換文字列では$1などで部分一致を参照できます）
+func (r ReplaceRule) Normalize(text string) string {
+	return r.Pattern.ReplaceAllString(text, r.Replacement)
+}
+
+// DefaultNormalizerNames は言語ごとの既定の正規化処理の名前を適用順に返します
+func DefaultNormalizerNames(languageCode string) []string {
+	names := []string{NormalizeEntities, NormalizeURLs, NormalizeSoundDescriptions}
+	// 大文字の話者名は英語など大文字を使う言語の字幕の慣習
+	if language := baseLanguage(languageCode); language != "ja" && language != "zh" && language != "ko" {
+		names = append(names, NormalizeSpeakerPrefixes)
+	}
+	return append(names, NormalizeDialogueDashes, NormalizeMusicNotes, NormalizeWhitespace)
+}
+
+// DefaultNormalizers は言語ごとの既定の正規化処理を返します
+func DefaultNormalizers(languageCode string) NormalizerChain {
+	chain := make(NormalizerChain, 0, len(DefaultNormalizerNames(languageCode)))
+	for _, name := range DefaultNormalizerNames(languageCode) {
+		normalizer, _ := BuiltinNormalizer(name, languageCode)
+		chain = append(chain, normalizer)
+	}
+	return chain
+}
+
+// BuiltinNormalizer は名前に対応する組み込みの正規化処理を返します
+func BuiltinNormalizer(name, languageCode string) (Normalizer, error) {
+	switch name {
+	case NormalizeEntities:
+		return NormalizerFunc(html.UnescapeString), nil
+	case NormalizeSoundDescriptions:
+		return soundDescriptionNormalizer(languageCode), nil
+	case NormalizeSpeakerPrefixes:
+		return NormalizerFunc(removeSpeakerPrefixes), nil
+	case NormalizeMusicNotes:
+		return NormalizerFunc(func(text string) string {
+			return musicNoteRegex.ReplaceAllString(text, " ")
+		}), nil
+	case NormalizeURLs:
+		return NormalizerFunc(func(text string) string {
+			return urlRegex.ReplaceAllString(text, "")
+		}), nil
+	case NormalizeDialogueDashes:
+		return NormalizerFunc(func(text string) string {
+			return dialogueDashRegex.ReplaceAllString(text, "$1")
+		}), nil
+	case NormalizeWhitespace:
+		return NormalizerFunc(collapseWhitespace), nil
+	default:
+		return nil, fmt.Errorf("不明な正規化処理です: %s", name)
+	}
+}
+
+// soundDescriptionNormalizer は効果音の説明を取り除く正規化処理を作成します
+// 角括弧で囲まれた部分はすべて、丸括弧で囲まれた部分は効果音を表す語、2語以上の大文字のみの説明、
+// または行頭の話者名（日本語など）の場合に取り除きます
+func soundDescriptionNormalizer(languageCode string) Normalizer {
+	language := baseLanguage(languageCode)
+	words := make(map[string]bool)
+	for _, word := range soundWords[language] {
+		words[word] = true
+	}
+
+	return NormalizerFunc(func(text string) string {
+		text = bracketedRegex.ReplaceAllString(text, " ")
+		if language == "ja" || language == "zh" {
+			text = leadingParentheticalRegex.ReplaceAllString(text, "")
+		}
+		return parentheticalRegex.ReplaceAllStringFunc(text, func(match string) string {
+			content := strings.TrimSpace(parentheticalRegex.FindStringSubmatch(match)[1])
+			if words[strings.ToLower(content)] || isUpperDescription(content) {
+				return " "
+			}
+			return match
+		})
+	})
+}
+
+// isUpperDescription は英字をすべて大文字で書いた2語以上の説明（MUSIC PLAYINGなど）かどうかを判定します
+// (NASA)のような1語の略語は説明とみなしません
+func isUpperDescription(text string) bool {
+	hasLetter := false
+	for _, r := range text {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			hasLetter = true
+		case r >= 'a' && r <= 'z':
+			return false
+		}
+	}
+	return hasLetter && len(strings.Fields(text)) >= 2
+}
+
+// removeSpeakerPrefixes は行頭の話者名（JOHN:）と話者の切り替えを表す">>"を取り除きます
+func removeSpeakerPrefixes(text string) string {
+	return speakerPrefixRegex.ReplaceAllString(text, "")
+}
+
+// collapseWhitespace は行ごとに連続する空白を1つにまとめ、空行を取り除きます
+func collapseWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(spacesRegex.ReplaceAllString(line, " ")); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// baseLanguage は言語コードの先頭部分（小文字）を返します
+func baseLanguage(languageCode string) string {
+	language, _, _ := strings.Cut(strings.ToLower(languageCode), "-")
+	return language
+}
+
+// normalizerRuleJSON は正規化ルールファイル内のユーザー定義のルールを表します
+type normalizerRuleJSON struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+	Language    string `json:"language"`
+}
+
+// LoadNormalizers はJSON形式の正規化ルールファイルを読み込み、言語に合わせた正規化処理を作成します
+// ユーザー定義のルールは組み込みの処理の後（空白の整理の前）に適用します
+//
+//	{
+//	  "defaults": true,
+//	  "disable": ["speaker-prefixes"],
+//	  "rules": [{"pattern": "\\bw/", "replacement": "with", "language": "en"}]
+//	}
+func LoadNormalizers(filePath, languageCode string) (NormalizerChain, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var config struct {
+		Defaults *bool                `json:"defaults"`
+		Disable  []string             `json:"disable"`
+		Rules    []normalizerRuleJSON `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("正規化ルールファイル %s の解析に失敗しました: %w", filePath, err)
+	}
+
+	disabled := make(map[string]bool, len(config.Disable))
+	for _, name := range config.Disable {
+		if _, err := BuiltinNormalizer(name, languageCode); err != nil {
+			return nil, err
+		}
+		disabled[name] = true
+	}
+
+	var names []string
+	if config.Defaults == nil || *config.Defaults {
+		names = DefaultNormalizerNames(languageCode)
+	}
+
+	chain := NormalizerChain{}
+	addBuiltin := func(name string) {
+		if disabled[name] {
+			return
+		}
+		normalizer, _ := BuiltinNormalizer(name, languageCode)
+		chain = append(chain, normalizer)
+	}
+	for _, name := range names {
+		if name != NormalizeWhitespace {
+			addBuiltin(name)
+		}
+	}
+
+	for i, rule := range config.Rules {
+		if rule.Language != "" && !matchesLanguage(rule.Language, languageCode) {
+			continue
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%d番目のルールのパターンが不正です: %w", i+1, err)
+		}
+		chain = append(chain, ReplaceRule{Pattern: pattern, Replacement: rule.Replacement})
+	}
+
+	if len(names) > 0 {
+		addBuiltin(NormalizeWhitespace)
+	}
+	return chain, nil
+}
+
+// matchesLanguage はルールの言語が変換の言語に一致するかどうかを判定します
+// "ja"のように地域を省略した場合は同じ言語のすべての地域に一致します
+func matchesLanguage(ruleLanguage, languageCode string) bool {
+	if strings.EqualFold(ruleLanguage, languageCode) {
+		return true
+	}
+	return !strings.Contains(ruleLanguage, "-") && strings.EqualFold(ruleLanguage, baseLanguage(languageCode))
+}
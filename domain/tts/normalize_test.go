@@ -0,0 +1,111 @@
+package tts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultNormalizers(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		input    string
+		want     string
+	}{
+		{"entities", "en-US", "Tom &amp; Jerry", "Tom & Jerry"},
+		{"bracketed sound", "en-US", "[MUSIC] Hello", "Hello"},
+		{"sound word", "en-US", "That's funny (laughs) really", "That's funny really"},
+		{"multi-word upper description", "en-US", "Wait (DOOR SLAMS) what", "Wait what"},
+		{"acronym survives", "en-US", "The agency (NASA) said", "The agency (NASA) said"},
+		{"lowercase aside survives", "en-US", "It costs (roughly) ten", "It costs (roughly) ten"},
+		{"speaker prefix", "en-US", "JOHN: Hello there", "Hello there"},
+		{"speaker change marker", "en-US", ">> Next question", "Next question"},
+		{"dialogue dash", "en-US", "- Are you coming?\n- Yes.", "Are you coming?\nYes."},
+		{"negative number survives", "en-US", "-5 degrees outside", "-5 degrees outside"},
+		{"dash before number survives", "en-US", "- 5 degrees outside", "- 5 degrees outside"},
+		{"music notes", "en-US", "♪ la la ♪", "la la"},
+		{"url", "en-US", "See https://example.com/a?b=1 now", "See now"},
+		{"japanese sound", "ja-JP", "（笑）それは違う", "それは違う"},
+		{"japanese speaker", "ja-JP", "（田中）こんにちは", "こんにちは"},
+		{"japanese keeps uppercase prefix", "ja-JP", "NHK: ニュース", "NHK: ニュース"},
+		{"whitespace", "en-US", "  a   b \n\n c  ", "a b\nc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DefaultNormalizers(tt.language).Normalize(tt.input)
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadNormalizers(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "rule for matching language",
+			config: `{"rules": [{"pattern": "\\bw/", "replacement": "with", "language": "en"}]}`,
+			input:  "coffee w/ milk",
+			want:   "coffee with milk",
+		},
+		{
+			name:   "rule for other language is skipped",
+			config: `{"rules": [{"pattern": "milk", "replacement": "tea", "language": "ja"}]}`,
+			input:  "coffee milk",
+			want:   "coffee milk",
+		},
+		{
+			name:   "disable builtin",
+			config: `{"disable": ["sound-descriptions"]}`,
+			input:  "[MUSIC] Hello",
+			want:   "[MUSIC] Hello",
+		},
+		{
+			name:   "no defaults",
+			config: `{"defaults": false, "rules": [{"pattern": "a", "replacement": "b"}]}`,
+			input:  "[a]  x",
+			want:   "[b]  x",
+		},
+		{
+			name:    "unknown builtin",
+			config:  `{"disable": ["nope"]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid pattern",
+			config:  `{"rules": [{"pattern": "("}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "rules.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			chain, err := LoadNormalizers(path, "en-US")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chain.Normalize(tt.input); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
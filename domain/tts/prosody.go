@@ -0,0 +1,118 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// MaxSpeakingRate はプロバイダーが受け付ける最大の話す速さです
+const MaxSpeakingRate = 4.0
+
+// プロバイダーが受け付ける韻律の値の範囲
+const (
+	minSpeakingRate = 0.25
+	minPitch        = -20.0
+	maxPitch        = 20.0
+	minVolumeGainDb = -96.0
+	maxVolumeGainDb = 16.0
+)
+
+// Prosody は話す速さ、声の高さ、音量などの音声の設定を表します
+// 0または空の値は設定を変更しないことを表します
+type Prosody struct {
+	SpeakingRate      float64  `json:"rate,omitempty"`            // 話す速さ（0.25〜4.0、1.0が標準）
+	Pitch             float64  `json:"pitch,omitempty"`           // 声の高さ（-20〜20の半音単位）
+	VolumeGainDb      float64  `json:"volume,omitempty"`          // 音量の増減（-96〜16dB）
+	SampleRateHertz   int      `json:"sample_rate,omitempty"`     // サンプルレート（Hz）
+	EffectsProfileIDs []string `json:"effects_profile,omitempty"` // 再生機器に合わせた効果（例: "headphone-class-device"）
+}
+
+// Validate は値がプロバイダーの受け付ける範囲にあるかどうかを確認します
+func (p Prosody) Validate() error {
+	if p.SpeakingRate != 0 && (p.SpeakingRate < minSpeakingRate || p.SpeakingRate > MaxSpeakingRate) {
+		return fmt.Errorf("話す速さは%.2f〜%.1fの範囲で指定してください: %v", minSpeakingRate, MaxSpeakingRate, p.SpeakingRate)
+	}
+	if p.Pitch < minPitch || p.Pitch > maxPitch {
+		return fmt.Errorf("声の高さは%.0f〜%.0fの範囲で指定してください: %v", minPitch, maxPitch, p.Pitch)
+	}
+	if p.VolumeGainDb < minVolumeGainDb || p.VolumeGainDb > maxVolumeGainDb {
+		return fmt.Errorf("音量は%.0f〜%.0fdBの範囲で指定してください: %v", minVolumeGainDb, maxVolumeGainDb, p.VolumeGainDb)
+	}
+	if p.SampleRateHertz < 0 {
+		return fmt.Errorf("サンプルレートは正の値で指定してください: %d", p.SampleRateHertz)
+	}
+	return nil
+}
+
+// Apply は設定されている値を音声設定に反映します
+func (p Prosody) Apply(config *AudioConfig) {
+	if p.SpeakingRate != 0 {
+		config.SpeakingRate = p.SpeakingRate
+	}
+	if p.Pitch != 0 {
+		config.Pitch = p.Pitch
+	}
+	if p.VolumeGainDb != 0 {
+		config.VolumeGainDb = p.VolumeGainDb
+	}
+	if p.SampleRateHertz != 0 {
+		config.SampleRateHertz = p.SampleRateHertz
+	}
+	if len(p.EffectsProfileIDs) > 0 {
+		config.EffectsProfileIDs = p.EffectsProfileIDs
+	}
+}
+
+// CueProsody はキュー識別子ごとの音声の設定を表します
+// 識別子のない字幕には適用されません
+type CueProsody map[string]Prosody
+
+// Lookup はキュー識別子に対応する音声の設定を返します
+// 複数の字幕を結合したキュー（"a+b"）の場合は、設定のある最初の字幕の設定を返します
+// 分割したキュー（"a#1"）の場合は、分割する前の字幕の設定を返します
+func (c CueProsody) Lookup(cueID string) (Prosody, bool) {
+	if cueID == "" {
+		return Prosody{}, false
+	}
+	if prosody, ok := c[cueID]; ok {
+		return prosody, true
+	}
+	for _, id := range strings.Split(cueID, "+") {
+		if prosody, ok := c[id]; ok {
+			return prosody, true
+		}
+		if i := strings.LastIndex(id, "#"); i >= 0 {
+			if prosody, ok := c[id[:i]]; ok {
+				return prosody, true
+			}
+		}
+	}
+	return Prosody{}, false
+}
+
+// LoadCueProsody はキュー識別子ごとの音声の設定を記述したJSONファイルを読み込みます
+//
+//	{
+//	  "intro-1": {"rate": 0.9, "pitch": -2, "volume": 3},
+//	  "outro": {"effects_profile": ["headphone-class-device"]}
+//	}
+func LoadCueProsody(filePath string) (CueProsody, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var cues CueProsody
+	if err := json.Unmarshal(data, &cues); err != nil {
+		return nil, fmt.Errorf("キューごとの音声設定ファイル %s の解析に失敗しました: %w", filePath, err)
+	}
+
+	for cueID, prosody := range cues {
+		if err := prosody.Validate(); err != nil {
+			return nil, fmt.Errorf("キュー %s の設定が不正です: %w", cueID, err)
+		}
+	}
+	return cues, nil
+}
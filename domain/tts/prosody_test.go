@@ -0,0 +1,30 @@
+package tts
+
+import "testing"
+
+func TestCueProsodyLookup(t *testing.T) {
+	cueProsody := CueProsody{
+		"intro": {SpeakingRate: 0.9},
+		"outro": {Pitch: -2},
+	}
+
+	tests := []struct {
+		cueID  string
+		want   Prosody
+		wantOK bool
+	}{
+		{"intro", Prosody{SpeakingRate: 0.9}, true},
+		{"a+outro", Prosody{Pitch: -2}, true},
+		{"intro#2", Prosody{SpeakingRate: 0.9}, true},
+		{"a+outro#1", Prosody{Pitch: -2}, true},
+		{"other", Prosody{}, false},
+		{"", Prosody{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := cueProsody.Lookup(tt.cueID)
+		if ok != tt.wantOK || got.SpeakingRate != tt.want.SpeakingRate || got.Pitch != tt.want.Pitch {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.cueID, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
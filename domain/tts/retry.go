@@ -0,0 +1,98 @@
+package tts
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// 再試行のデフォルト値
+const (
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultRequestTimeout = 30 * time.Second
+)
+
+// SynthesisError はプロバイダーから返された音声合成のエラーを、再試行できるかどうかとともに表します
+type SynthesisError struct {
+	Code      string // プロバイダーのエラーコード（例: "Unavailable"）
+	Retryable bool   // 一時的なエラーで、再試行すると成功する可能性があるかどうか
+	Err       error  // プロバイダーから返されたエラー
+}
+
+// Error はプロバイダーから返されたエラーのメッセージを返します
+func (e *SynthesisError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap はプロバイダーから返されたエラーを返します
+func (e *SynthesisError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable はエラーが再試行できる一時的なエラーかどうかを判定します
+func IsRetryable(err error) bool {
+	var synthesisErr *SynthesisError
+	return errors.As(err, &synthesisErr) && synthesisErr.Retryable
+}
+
+// RetryOptions は一時的なエラーで音声合成に失敗した場合の再試行の設定を表します
+type RetryOptions struct {
+	MaxAttempts    int           // 最初の試行を含む最大の試行回数（0の場合は5、1の場合は再試行しない）
+	InitialBackoff time.Duration // 最初の再試行までの待機時間（0の場合は500ミリ秒）
+	MaxBackoff     time.Duration // 再試行までの最大の待機時間（0の場合は30秒）
+	Timeout        time.Duration // 1回のリクエストの制限時間（0の場合は30秒）
+	// OnRetry は再試行まで待機する前に呼び出す関数（nilの場合は何もしない）
+	// 複数のリクエストを並行して合成する場合は同時に呼び出されることがあります
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent は一時的なエラーで音声合成に失敗し、再試行することを表します
+type RetryEvent struct {
+	Index       int           // 元になった字幕の番号（0始まり）
+	CueID       string        // リクエストの元になった字幕のキュー識別子
+	Attempt     int           // 次の試行の回数（1始まり）
+	MaxAttempts int           // 最初の試行を含む最大の試行回数
+	Backoff     time.Duration // 次の試行までの待機時間
+	Err         error         // 失敗した試行のエラー
+}
+
+// Attempts は最初の試行を含む最大の試行回数を返します
+func (o RetryOptions) Attempts() int {
+	if o.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return o.MaxAttempts
+}
+
+// RequestTimeout は1回のリクエストの制限時間を返します
+func (o RetryOptions) RequestTimeout() time.Duration {
+	if o.Timeout <= 0 {
+		return defaultRequestTimeout
+	}
+	return o.Timeout
+}
+
+// Backoff はattempt回目（1始まり）の試行に失敗した後、次の試行までに待機する時間を返します
+// 待機時間は試行のたびに2倍（最大の待機時間まで）になり、同時に再試行が集中しないように
+// その半分から全体までの範囲でランダムにずらします
+func (o RetryOptions) Backoff(attempt int) time.Duration {
+	initial := o.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	limit := o.MaxBackoff
+	if limit <= 0 {
+		limit = defaultMaxBackoff
+	}
+
+	backoff := initial
+	for i := 1; i < attempt && backoff < limit; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, limit)
+
+	half := backoff / 2
+	return half + rand.N(backoff-half+1)
+}
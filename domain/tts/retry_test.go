@@ -0,0 +1,71 @@
+package tts
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryOptionsBackoff(t *testing.T) {
+	options := RetryOptions{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 100 * time.Millisecond},
+		{2, 200 * time.Millisecond},
+		{3, 400 * time.Millisecond},
+		{4, 800 * time.Millisecond},
+		{5, time.Second},
+		{10, time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			for range 20 {
+				got := options.Backoff(tt.attempt)
+				if got < tt.want/2 || got > tt.want {
+					t.Fatalf("Backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.want/2, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryOptionsDefaults(t *testing.T) {
+	var options RetryOptions
+	if got := options.Attempts(); got != defaultMaxAttempts {
+		t.Errorf("Attempts() = %d, want %d", got, defaultMaxAttempts)
+	}
+	if got := options.RequestTimeout(); got != defaultRequestTimeout {
+		t.Errorf("RequestTimeout() = %v, want %v", got, defaultRequestTimeout)
+	}
+	if got := (RetryOptions{MaxAttempts: 1}).Attempts(); got != 1 {
+		t.Errorf("Attempts() = %d, want 1", got)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cause := errors.New("unavailable")
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"retryable", &SynthesisError{Code: "Unavailable", Retryable: true, Err: cause}, true},
+		{"wrapped", fmt.Errorf("字幕 1: %w", &SynthesisError{Retryable: true, Err: cause}), true},
+		{"permanent", &SynthesisError{Code: "InvalidArgument", Err: cause}, false},
+		{"other", cause, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
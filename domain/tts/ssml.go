@@ -0,0 +1,284 @@
+package tts
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrInvalidSSML はSSMLドキュメントが不正な場合のエラーです
+var ErrInvalidSSML = errors.New("invalid SSML")
+
+// maxSSMLBytes はプロバイダーが1回のリクエストで受け付けるSSMLの最大バイト数です
+const maxSSMLBytes = 5000
+
+// 強調の区間を表す目印
+// 自動でSSMLを生成する際、読み上げ用のテキストの強調（<b>, <i>）の開始と終了に挿入します
+const (
+	EmphasisStart = "\uE000"
+	EmphasisEnd   = "\uE001"
+)
+
+// SSMLを自動で生成する際の間の長さ
+const (
+	ellipsisBreak  = `<break time="500ms"/>`
+	lineBreakBreak = `<break strength="weak"/>`
+)
+
+// ssmlTokenRegex は自動でSSMLに変換する表記（日付、電話番号、数値、省略記号、改行）を表します
+// 小数や時刻などの数値を含む表記は誤って数値として読まれないようにそのまま残します
+var ssmlTokenRegex = regexp.MustCompile(
+	`(?P<ymd>\b\d{4}[-/]\d{1,2}[-/]\d{1,2}\b)` +
+		`|(?P<date>\b\d{1,2}/\d{1,2}/\d{4}\b)` +
+		`|(?P<telephone>\+\d{1,3}(?:[ -]\d{1,4}){2,4}\b|\(\d{2,4}\) ?\d{2,4}-\d{4}\b|\b0\d{1,4}-\d{1,4}-\d{4}\b|\b\d{3}-\d{3}-\d{4}\b)` +
+		`|(?P<verbatim>\d+(?:[.:]\d+)+)` +
+		`|(?P<cardinal>\b\d{1,3}(?:,\d{3})+\b|\b\d+\b)` +
+		`|(?P<ellipsis>…+|‥+|\.{3,})` +
+		`|(?P<newline>\n)`)
+
+// ssmlBreakTimeRegex は<break>のtime属性の値（"500ms", "1.5s"）を表します
+var ssmlBreakTimeRegex = regexp.MustCompile(`^\d+(?:\.\d+)?(?:ms|s)$`)
+
+// ssmlElements はSSMLドキュメントで使用できる要素と、値を確認する属性の対応です
+var ssmlElements = map[string]map[string][]string{
+	"speak":    nil,
+	"p":        nil,
+	"s":        nil,
+	"sub":      nil,
+	"mark":     nil,
+	"audio":    nil,
+	"desc":     nil,
+	"lang":     nil,
+	"voice":    nil,
+	"prosody":  nil,
+	"par":      nil,
+	"seq":      nil,
+	"media":    nil,
+	"break":    {"strength": {"none", "x-weak", "weak", "medium", "strong", "x-strong"}},
+	"emphasis": {"level": {"strong", "moderate", "none", "reduced"}},
+	"say-as":   nil,
+	"phoneme":  {"alphabet": {AlphabetIPA, AlphabetXSAMPA}},
+}
+
+// StripEmphasis はテキストから強調の目印を取り除きます
+func StripEmphasis(text string) string {
+	return strings.NewReplacer(EmphasisStart, "", EmphasisEnd, "").Replace(text)
+}
+
+// GenerateSSML は読み上げ用のテキストからSSMLドキュメントを生成します
+// 省略記号と改行は<break>、日付・電話番号・数値は<say-as>、強調の目印で囲まれた区間は<emphasis>に変換します
+// 発音辞書が指定されている場合は、置換テキストを適用し、発音記号を指定した語を<phoneme>に変換します
+func GenerateSSML(text, languageCode string, lexicon *Lexicon) string {
+	var ssml strings.Builder
+	ssml.WriteString("<speak>")
+
+	emphasis := false
+	for _, segment := range lexicon.Split(text, languageCode) {
+		if segment.Phoneme != "" {
+			writePhoneme(&ssml, segment)
+			continue
+		}
+
+		// 強調の目印で区切りながら書き込む（対応しない目印は無視する）
+		rest := segment.Text
+		for rest != "" {
+			i := strings.IndexAny(rest, EmphasisStart+EmphasisEnd)
+			if i < 0 {
+				writeSSMLText(&ssml, rest, languageCode)
+				break
+			}
+			writeSSMLText(&ssml, rest[:i], languageCode)
+
+			marker, size := utf8.DecodeRuneInString(rest[i:])
+			switch {
+			case string(marker) == EmphasisStart && !emphasis:
+				ssml.WriteString(`<emphasis level="moderate">`)
+				emphasis = true
+			case string(marker) == EmphasisEnd && emphasis:
+				ssml.WriteString("</emphasis>")
+				emphasis = false
+			}
+			rest = rest[i+size:]
+		}
+	}
+	if emphasis {
+		ssml.WriteString("</emphasis>")
+	}
+
+	ssml.WriteString("</speak>")
+	return ssml.String()
+}
+
+// writeSSMLText はテキストの日付や数値などをSSMLの要素に変換し、それ以外をエスケープして書き込みます
+func writeSSMLText(ssml *strings.Builder, text, languageCode string) {
+	last := 0
+	names := ssmlTokenRegex.SubexpNames()
+	for _, match := range ssmlTokenRegex.FindAllStringSubmatchIndex(text, -1) {
+		ssml.WriteString(EscapeSSML(text[last:match[0]]))
+		last = match[1]
+
+		token := text[match[0]:match[1]]
+		name := ""
+		for i := 1; i < len(names); i++ {
+			if match[i*2] >= 0 {
+				name = names[i]
+				break
+			}
+		}
+
+		switch name {
+		case "ymd":
+			fmt.Fprintf(ssml, `<say-as interpret-as="date" format="ymd">%s</say-as>`, token)
+		case "date":
+			// 月と日の順序は言語と地域によって異なる
+			format := "dmy"
+			if normalizeLanguageKey(languageCode) == "en-us" {
+				format = "mdy"
+			}
+			fmt.Fprintf(ssml, `<say-as interpret-as="date" format="%s">%s</say-as>`, format, token)
+		case "telephone":
+			fmt.Fprintf(ssml, `<say-as interpret-as="telephone">%s</say-as>`, token)
+		case "cardinal":
+			fmt.Fprintf(ssml, `<say-as interpret-as="cardinal">%s</say-as>`, strings.ReplaceAll(token, ",", ""))
+		case "ellipsis":
+			ssml.WriteString(ellipsisBreak)
+		case "newline":
+			ssml.WriteString(lineBreakBreak)
+		default:
+			ssml.WriteString(EscapeSSML(token))
+		}
+	}
+	ssml.WriteString(EscapeSSML(text[last:]))
+}
+
+// IsSSML はテキストがSSMLドキュメント（<speak>要素）として記述されているかどうかを判定します
+func IsSSML(text string) bool {
+	text = strings.TrimSpace(text)
+	return strings.HasPrefix(text, "<speak>") || strings.HasPrefix(text, "<speak ")
+}
+
+// StripSSML はSSMLドキュメントから要素を取り除き、読み上げるテキストを返します
+// 字幕として表示するためのもので、整形式でない場合は元のテキストを返します
+func StripSSML(ssml string) string {
+	var text strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader(ssml))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ssml
+		}
+		if data, ok := token.(xml.CharData); ok {
+			text.Write(data)
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// ValidateSSML はSSMLドキュメントが送信できる形式かどうかを確認します
+// 整形式のXMLであること、ルート要素が<speak>であること、使用できる要素と属性の値であること、
+// およびプロバイダーの上限のサイズに収まることを確認します
+func ValidateSSML(ssml string) error {
+	if len(ssml) > maxSSMLBytes {
+		return fmt.Errorf("%w: %dバイトを超えています（%dバイト）", ErrInvalidSSML, maxSSMLBytes, len(ssml))
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(ssml))
+	depth := 0
+	rootClosed := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSSML, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if rootClosed {
+				return fmt.Errorf("%w: <speak>要素の後に要素があります: <%s>", ErrInvalidSSML, t.Name.Local)
+			}
+			if depth == 0 && t.Name.Local != "speak" {
+				return fmt.Errorf("%w: ルート要素は<speak>である必要があります: <%s>", ErrInvalidSSML, t.Name.Local)
+			}
+			if depth > 0 && t.Name.Local == "speak" {
+				return fmt.Errorf("%w: <speak>要素は入れ子にできません", ErrInvalidSSML)
+			}
+			if err := validateSSMLElement(t); err != nil {
+				return err
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				rootClosed = true
+			}
+		case xml.CharData:
+			if depth == 0 && strings.TrimSpace(string(t)) != "" {
+				return fmt.Errorf("%w: <speak>要素の外にテキストがあります", ErrInvalidSSML)
+			}
+		}
+	}
+
+	if !rootClosed {
+		return fmt.Errorf("%w: <speak>要素がありません", ErrInvalidSSML)
+	}
+	return nil
+}
+
+// validateSSMLElement は要素の名前と属性の値を確認します
+func validateSSMLElement(element xml.StartElement) error {
+	// Google独自の拡張（google:style など）は名前空間付きで記述されるため確認しない
+	if element.Name.Space != "" {
+		return nil
+	}
+
+	name := element.Name.Local
+	attributes, ok := ssmlElements[name]
+	if !ok {
+		return fmt.Errorf("%w: 使用できない要素です: <%s>", ErrInvalidSSML, name)
+	}
+
+	values := make(map[string]string, len(element.Attr))
+	for _, attr := range element.Attr {
+		values[attr.Name.Local] = attr.Value
+	}
+	for attribute, allowed := range attributes {
+		value, ok := values[attribute]
+		if !ok {
+			continue
+		}
+		if !slices.Contains(allowed, value) {
+			return fmt.Errorf("%w: <%s>の%s属性の値が不正です（%s）: %s", ErrInvalidSSML, name, attribute, strings.Join(allowed, ", "), value)
+		}
+	}
+
+	switch name {
+	case "break":
+		if value, ok := values["time"]; ok && !ssmlBreakTimeRegex.MatchString(value) {
+			return fmt.Errorf("%w: <break>のtime属性の値が不正です: %s", ErrInvalidSSML, value)
+		}
+	case "say-as":
+		if values["interpret-as"] == "" {
+			return fmt.Errorf("%w: <say-as>にはinterpret-as属性が必要です", ErrInvalidSSML)
+		}
+	case "phoneme":
+		if values["ph"] == "" {
+			return fmt.Errorf("%w: <phoneme>にはph属性が必要です", ErrInvalidSSML)
+		}
+	case "sub":
+		if values["alias"] == "" {
+			return fmt.Errorf("%w: <sub>にはalias属性が必要です", ErrInvalidSSML)
+		}
+	}
+	return nil
+}
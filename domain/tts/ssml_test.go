@@ -0,0 +1,86 @@
+package tts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSSML(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		text     string
+		want     string
+	}{
+		{"escape", "en-US", "Tom & Jerry <b>", "<speak>Tom &amp; Jerry &lt;b&gt;</speak>"},
+		{"ellipsis and newline", "ja-JP", "えっと…\nはい", `<speak>えっと<break time="500ms"/><break strength="weak"/>はい</speak>`},
+		{"ymd date", "ja-JP", "2024-01-15に", `<speak><say-as interpret-as="date" format="ymd">2024-01-15</say-as>に</speak>`},
+		{"us date", "en-US", "on 1/15/2024", `<speak>on <say-as interpret-as="date" format="mdy">1/15/2024</say-as></speak>`},
+		{"telephone", "ja-JP", "03-1234-5678", `<speak><say-as interpret-as="telephone">03-1234-5678</say-as></speak>`},
+		{"cardinal", "en-US", "1,000 people", `<speak><say-as interpret-as="cardinal">1000</say-as> people</speak>`},
+		{"version is verbatim", "en-US", "v3.14", "<speak>v3.14</speak>"},
+		{"emphasis", "en-US", "a " + EmphasisStart + "big" + EmphasisEnd + " deal", `<speak>a <emphasis level="moderate">big</emphasis> deal</speak>`},
+		{"unclosed emphasis", "en-US", EmphasisStart + "big", `<speak><emphasis level="moderate">big</emphasis></speak>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GenerateSSML(tt.text, tt.language, nil)
+			if got != tt.want {
+				t.Errorf("GenerateSSML(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+			if err := ValidateSSML(got); err != nil {
+				t.Errorf("generated SSML is invalid: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateSSML(t *testing.T) {
+	tests := []struct {
+		name    string
+		ssml    string
+		wantErr bool
+	}{
+		{"valid", `<speak>Hello <break time="1s"/><say-as interpret-as="cardinal">3</say-as></speak>`, false},
+		{"google extension", `<speak xmlns:google="https://cloud.google.com"><google:style name="lively">Hi</google:style></speak>`, false},
+		{"not well formed", `<speak>Hello`, true},
+		{"wrong root", `<p>Hello</p>`, true},
+		{"text outside root", `<speak>Hello</speak> world`, true},
+		{"unknown element", `<speak><blink>Hi</blink></speak>`, true},
+		{"invalid attribute value", `<speak><break strength="huge"/></speak>`, true},
+		{"invalid break time", `<speak><break time="soon"/></speak>`, true},
+		{"missing interpret-as", `<speak><say-as>3</say-as></speak>`, true},
+		{"too long", "<speak>" + strings.Repeat("a", maxSSMLBytes) + "</speak>", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSSML(tt.ssml)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateSSML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSSML) {
+				t.Errorf("error %v does not wrap ErrInvalidSSML", err)
+			}
+		})
+	}
+}
+
+func TestStripSSML(t *testing.T) {
+	tests := []struct {
+		ssml string
+		want string
+	}{
+		{`<speak>少々お待ちください。<break time="1s"/>お待たせしました。</speak>`, "少々お待ちください。お待たせしました。"},
+		{`<speak>Tom &amp; <emphasis>Jerry</emphasis></speak>`, "Tom & Jerry"},
+		{`<speak>broken`, `<speak>broken`},
+	}
+
+	for _, tt := range tests {
+		if got := StripSSML(tt.ssml); got != tt.want {
+			t.Errorf("StripSSML(%q) = %q, want %q", tt.ssml, got, tt.want)
+		}
+	}
+}
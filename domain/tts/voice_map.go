@@ -0,0 +1,214 @@
+package tts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// voiceNameLanguageRegex は声の名前に含まれる言語コード（例: "ja-JP-Neural2-B"の"ja-JP"）を表します
+var voiceNameLanguageRegex = regexp.MustCompile(`^([a-z]{2,3}-[A-Z]{2})-`)
+
+// defaultVoicePool はマッピングのない話者に順番に割り当てる既定の声の一覧です
+// 性別と声の高さを変えることで、声の名前を指定しなくても話者を聞き分けられるようにします
+var defaultVoicePool = []VoiceProfile{
+	{Gender: Female},
+	{Gender: Male},
+	{Gender: Female, Pitch: 3},
+	{Gender: Male, Pitch: -3},
+	{Gender: Female, Pitch: -3},
+	{Gender: Male, Pitch: 3},
+}
+
+// VoiceProfile は話者に割り当てる声の設定を表します
+type VoiceProfile struct {
+	Name         string      // 声の名前（例: "ja-JP-Neural2-B"）
+	Gender       VoiceGender // 声の性別
+	LanguageCode string      // 言語コード（空の場合は声の名前または全体の設定から決定）
+	SpeakingRate float64     // 話す速さ（0は既定値）
+	Pitch        float64     // 声の高さ（半音単位）
+}
+
+// Apply は声の設定をリクエストの音声選択パラメータと音声設定に反映します
+func (p VoiceProfile) Apply(voice *VoiceSelectionParams, config *AudioConfig) {
+	voice.Gender = p.Gender
+	if p.Name != "" {
+		voice.Name = p.Name
+		// 声の名前と言語コードが一致しないとプロバイダーがエラーを返すため、名前から言語を補う
+		if matches := voiceNameLanguageRegex.FindStringSubmatch(p.Name); matches != nil && p.LanguageCode == "" {
+			voice.LanguageCode = matches[1]
+		}
+	}
+	if p.LanguageCode != "" {
+		voice.LanguageCode = p.LanguageCode
+	}
+	if p.SpeakingRate != 0 {
+		config.SpeakingRate = p.SpeakingRate
+	}
+	if p.Pitch != 0 {
+		config.Pitch = p.Pitch
+	}
+}
+
+// voiceProfileJSON はマッピングファイル内の声の設定を表します
+type voiceProfileJSON struct {
+	Name     string  `json:"name"`
+	Gender   string  `json:"gender"`
+	Language string  `json:"language"`
+	Rate     float64 `json:"rate"`
+	Pitch    float64 `json:"pitch"`
+}
+
+// toProfile はマッピングファイルの内容をVoiceProfileに変換します
+func (v voiceProfileJSON) toProfile() (VoiceProfile, error) {
+	gender, err := ParseVoiceGender(v.Gender)
+	if err != nil {
+		return VoiceProfile{}, err
+	}
+	return VoiceProfile{
+		Name:         v.Name,
+		Gender:       gender,
+		LanguageCode: v.Language,
+		SpeakingRate: v.Rate,
+		Pitch:        v.Pitch,
+	}, nil
+}
+
+// ParseVoiceProfile は"ja-JP-Neural2-B,gender:female,rate:1.1,pitch:-2,lang:ja-JP"形式の文字列を解析します
+// "key:value"形式でない項目は声の名前として扱います
+func ParseVoiceProfile(spec string) (VoiceProfile, error) {
+	profile := VoiceProfile{Gender: Neutral}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(item, ":")
+		if !found {
+			profile.Name = item
+			continue
+		}
+
+		var err error
+		switch strings.ToLower(key) {
+		case "name":
+			profile.Name = value
+		case "gender":
+			profile.Gender, err = ParseVoiceGender(value)
+		case "lang", "language":
+			profile.LanguageCode = value
+		case "rate":
+			profile.SpeakingRate, err = strconv.ParseFloat(value, 64)
+		case "pitch":
+			profile.Pitch, err = strconv.ParseFloat(value, 64)
+		default:
+			err = fmt.Errorf("不明な項目です: %s", key)
+		}
+		if err != nil {
+			return VoiceProfile{}, fmt.Errorf("声の設定 %q の解析に失敗しました: %w", spec, err)
+		}
+	}
+
+	return profile, nil
+}
+
+// SpeakerVoices は話者名から声の設定への対応を管理します
+// マッピングのない話者には、初めて登場した順にプールから声を割り当てます
+type SpeakerVoices struct {
+	speakers map[string]VoiceProfile
+	pool     []VoiceProfile
+	next     int
+}
+
+// NewSpeakerVoices は新しいSpeakerVoicesを作成します
+// poolが空の場合は既定の声のプールを使用します
+func NewSpeakerVoices(speakers map[string]VoiceProfile, pool []VoiceProfile) *SpeakerVoices {
+	if len(pool) == 0 {
+		pool = defaultVoicePool
+	}
+	mapped := make(map[string]VoiceProfile, len(speakers))
+	for speaker, profile := range speakers {
+		mapped[speaker] = profile
+	}
+	return &SpeakerVoices{
+		speakers: mapped,
+		pool:     pool,
+	}
+}
+
+// Set は話者に声の設定を割り当てます
+func (m *SpeakerVoices) Set(speaker string, profile VoiceProfile) {
+	m.speakers[speaker] = profile
+}
+
+// SetPool はマッピングのない話者に割り当てる声のプールを置き換えます
+func (m *SpeakerVoices) SetPool(pool []VoiceProfile) {
+	if len(pool) > 0 {
+		m.pool = pool
+	}
+}
+
+// Lookup はマッピングされた話者の声の設定を返します（プールからの割り当ては行いません）
+func (m *SpeakerVoices) Lookup(speaker string) (VoiceProfile, bool) {
+	profile, ok := m.speakers[speaker]
+	return profile, ok
+}
+
+// VoiceFor は話者の声の設定を返します
+// マッピングのない話者にはプールから声を割り当て、以降は同じ声を返します
+func (m *SpeakerVoices) VoiceFor(speaker string) VoiceProfile {
+	if profile, ok := m.speakers[speaker]; ok {
+		return profile
+	}
+
+	profile := m.pool[m.next%len(m.pool)]
+	m.next++
+	m.speakers[speaker] = profile
+	return profile
+}
+
+// LoadSpeakerVoices はJSON形式のマッピングファイルを読み込みます
+//
+//	{
+//	  "speakers": {"Alice": {"name": "ja-JP-Neural2-B", "rate": 1.1}},
+//	  "pool": [{"gender": "female"}, {"gender": "male", "pitch": -2}]
+//	}
+func LoadSpeakerVoices(filePath string) (*SpeakerVoices, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var mapping struct {
+		Speakers map[string]voiceProfileJSON `json:"speakers"`
+		Pool     []voiceProfileJSON          `json:"pool"`
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("話者マッピングファイル %s の解析に失敗しました: %w", filePath, err)
+	}
+
+	speakers := make(map[string]VoiceProfile, len(mapping.Speakers))
+	for speaker, v := range mapping.Speakers {
+		profile, err := v.toProfile()
+		if err != nil {
+			return nil, fmt.Errorf("話者 %s の設定が不正です: %w", speaker, err)
+		}
+		speakers[speaker] = profile
+	}
+
+	pool := make([]VoiceProfile, 0, len(mapping.Pool))
+	for i, v := range mapping.Pool {
+		profile, err := v.toProfile()
+		if err != nil {
+			return nil, fmt.Errorf("プールの%d番目の設定が不正です: %w", i+1, err)
+		}
+		pool = append(pool, profile)
+	}
+
+	return NewSpeakerVoices(speakers, pool), nil
+}
@@ -0,0 +1,167 @@
+package tts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseVoiceProfile(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    VoiceProfile
+		wantErr bool
+	}{
+		{spec: "ja-JP-Neural2-B", want: VoiceProfile{Name: "ja-JP-Neural2-B", Gender: Neutral}},
+		{
+			spec: "ja-JP-Neural2-B, gender:female, rate:1.1, pitch:-2, lang:ja-JP",
+			want: VoiceProfile{Name: "ja-JP-Neural2-B", Gender: Female, LanguageCode: "ja-JP", SpeakingRate: 1.1, Pitch: -2},
+		},
+		{spec: "name:en-US-Wavenet-D,language:en-US", want: VoiceProfile{Name: "en-US-Wavenet-D", Gender: Neutral, LanguageCode: "en-US"}},
+		{spec: "gender:male,,", want: VoiceProfile{Gender: Male}},
+		{spec: "", want: VoiceProfile{Gender: Neutral}},
+		{spec: "gender:robot", wantErr: true},
+		{spec: "rate:fast", wantErr: true},
+		{spec: "volume:3", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseVoiceProfile(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVoiceProfile(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpeakerVoicesPoolRotation(t *testing.T) {
+	alice := VoiceProfile{Name: "ja-JP-Neural2-B"}
+	pool := []VoiceProfile{{Gender: Female}, {Gender: Male}}
+	voices := NewSpeakerVoices(map[string]VoiceProfile{"Alice": alice}, pool)
+
+	tests := []struct {
+		speaker string
+		want    VoiceProfile
+	}{
+		{"Alice", alice},
+		{"Bob", pool[0]},
+		{"Carol", pool[1]},
+		{"Bob", pool[0]},
+		{"Dave", pool[0]},
+		{"Alice", alice},
+	}
+
+	for i, tt := range tests {
+		if got := voices.VoiceFor(tt.speaker); got != tt.want {
+			t.Errorf("step %d: VoiceFor(%q) = %+v, want %+v", i, tt.speaker, got, tt.want)
+		}
+	}
+
+	// プールから割り当てた話者は以降マッピング済みとして扱う
+	if got, ok := voices.Lookup("Carol"); !ok || got != pool[1] {
+		t.Errorf("Lookup(Carol) = %+v, %v, want %+v, true", got, ok, pool[1])
+	}
+	if _, ok := voices.Lookup("Eve"); ok {
+		t.Error("Lookup(Eve) should not assign a voice from the pool")
+	}
+}
+
+func TestSpeakerVoicesDefaultPool(t *testing.T) {
+	voices := NewSpeakerVoices(nil, nil)
+	for i, want := range defaultVoicePool {
+		if got := voices.VoiceFor(string(rune('A' + i))); got != want {
+			t.Errorf("speaker %d = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestLoadSpeakerVoices(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		speakers map[string]VoiceProfile
+		pool     []VoiceProfile
+		wantErr  bool
+	}{
+		{
+			name: "speakers and pool",
+			config: `{
+				"speakers": {"Alice": {"name": "ja-JP-Neural2-B", "rate": 1.1}},
+				"pool": [{"gender": "female"}, {"gender": "male", "pitch": -2, "language": "en-US"}]
+			}`,
+			speakers: map[string]VoiceProfile{"Alice": {Name: "ja-JP-Neural2-B", Gender: Neutral, SpeakingRate: 1.1}},
+			pool:     []VoiceProfile{{Gender: Female}, {Gender: Male, Pitch: -2, LanguageCode: "en-US"}},
+		},
+		{
+			name:     "empty pool uses default",
+			config:   `{"speakers": {"Bob": {"gender": "male"}}}`,
+			speakers: map[string]VoiceProfile{"Bob": {Gender: Male}},
+			pool:     defaultVoicePool,
+		},
+		{
+			name:    "invalid speaker gender",
+			config:  `{"speakers": {"Alice": {"gender": "robot"}}}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid pool gender",
+			config:  `{"pool": [{"gender": "robot"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			config:  `{"speakers": [}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "voices.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			voices, err := LoadSpeakerVoices(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for speaker, want := range tt.speakers {
+				if got, ok := voices.Lookup(speaker); !ok || got != want {
+					t.Errorf("Lookup(%q) = %+v, %v, want %+v, true", speaker, got, ok, want)
+				}
+			}
+			if len(voices.pool) != len(tt.pool) {
+				t.Fatalf("pool = %+v, want %+v", voices.pool, tt.pool)
+			}
+			for i, want := range tt.pool {
+				if voices.pool[i] != want {
+					t.Errorf("pool[%d] = %+v, want %+v", i, voices.pool[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSpeakerVoicesMissingFile(t *testing.T) {
+	if _, err := LoadSpeakerVoices(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error")
+	}
+}
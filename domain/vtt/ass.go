@@ -0,0 +1,198 @@
+package vtt
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidASSEvents はASS/SSAファイルに[Events]セクションのFormat行がない場合のエラーです
+var ErrInvalidASSEvents = errors.New("invalid ASS/SSA file: missing [Events] format line")
+
+// ASS/SSA形式を解析するためのパターン
+var (
+	// assTimeRegex はASSの時間（H:MM:SS.cc）を表します
+	assTimeRegex = regexp.MustCompile(`^(\d+):(\d{1,2}):(\d{1,2})(?:\.(\d{1,3}))?$`)
+	// assOverrideRegex は上書きタグのブロック（{\an8\i1}など）を表します
+	assOverrideRegex = regexp.MustCompile(`\{[^}]*\}`)
+	// assTagRegex は上書きタグのブロック内の個々のタグ名と引数を表します
+	assTagRegex = regexp.MustCompile(`\\([a-z]+)(\d*)`)
+)
+
+// assDefaultFormat はFormat行が省略された場合の[Events]の列の並びです
+var assDefaultFormat = []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+// ParseASS はリーダーからASS/SSA形式のデータを解析します
+// [Events]セクションのDialogue行を字幕とし、Name（SSAではActor）列は<v>タグ、Style列はSubtitle.Styleとして保持します
+func ParseASS(r io.Reader) (*VTTFile, error) {
+	scanner := newLineScanner(r)
+	vttFile := &VTTFile{Subtitles: []Subtitle{}}
+
+	inEvents := false
+	foundEvents := false
+	var format []string
+
+	lineNumber := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		lineNumber++
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			foundEvents = foundEvents || inEvents
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+
+		switch strings.TrimSpace(key) {
+		case "Format":
+			format = nil
+			for _, column := range strings.Split(value, ",") {
+				format = append(format, strings.ToLower(strings.TrimSpace(column)))
+			}
+		case "Dialogue":
+			if format == nil {
+				format = assDefaultFormat
+			}
+			if subtitle, ok := parseASSDialogue(value, format); ok {
+				subtitle.Line = lineNumber
+				vttFile.Subtitles = append(vttFile.Subtitles, subtitle)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !foundEvents {
+		return nil, ErrInvalidASSEvents
+	}
+
+	return vttFile, nil
+}
+
+// parseASSDialogue はDialogue行の値を列の並びに従って字幕に変換します
+// Text列は最後の列で、カンマを含むことがあります
+func parseASSDialogue(value string, format []string) (Subtitle, bool) {
+	fields := strings.SplitN(value, ",", len(format))
+	if len(fields) != len(format) {
+		return Subtitle{}, false
+	}
+
+	columns := make(map[string]string, len(format))
+	for i, name := range format {
+		columns[name] = fields[i]
+		if name != "text" {
+			columns[name] = strings.TrimSpace(fields[i])
+		}
+	}
+
+	startTime, err := parseASSTime(columns["start"])
+	if err != nil {
+		return Subtitle{}, false
+	}
+	endTime, err := parseASSTime(columns["end"])
+	if err != nil {
+		return Subtitle{}, false
+	}
+
+	text := convertASSText(columns["text"])
+	if strings.TrimSpace(text) == "" {
+		return Subtitle{}, false
+	}
+
+	speaker := columns["name"]
+	if speaker == "" {
+		speaker = columns["actor"]
+	}
+	if speaker != "" {
+		text = "<v " + EscapeText(speaker) + ">" + text
+	}
+
+	return Subtitle{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Text:      text,
+		Style:     strings.TrimPrefix(columns["style"], "*"),
+	}, true
+}
+
+// parseASSTime はASSの時間（H:MM:SS.cc）をtime.Durationに変換します
+func parseASSTime(value string) (time.Duration, error) {
+	matches := assTimeRegex.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, ErrInvalidTimestamp
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	// 小数部は通常センチ秒（2桁）なので、ミリ秒に揃える
+	milliseconds, _ := strconv.Atoi((matches[4] + "000")[:3])
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(milliseconds)*time.Millisecond, nil
+}
+
+// convertASSText はASSのテキストをWebVTTのキューテキストに変換します
+// 斜体・太字・下線の上書きタグは<i>, <b>, <u>タグに変換し、その他の上書きタグは削除します
+// \Nは改行、\nと\hは空白として扱います
+func convertASSText(text string) string {
+	var builder strings.Builder
+	open := map[string]bool{}
+	order := []string{"i", "b", "u"}
+
+	closeAll := func() {
+		for i := len(order) - 1; i >= 0; i-- {
+			if open[order[i]] {
+				builder.WriteString("</" + order[i] + ">")
+				open[order[i]] = false
+			}
+		}
+	}
+
+	last := 0
+	for _, loc := range assOverrideRegex.FindAllStringIndex(text, -1) {
+		builder.WriteString(convertASSPlainText(text[last:loc[0]]))
+		last = loc[1]
+
+		for _, tag := range assTagRegex.FindAllStringSubmatch(text[loc[0]:loc[1]], -1) {
+			name, argument := tag[1], tag[2]
+			switch name {
+			case "i", "b", "u":
+				enable := argument != "" && argument != "0"
+				if enable && !open[name] {
+					builder.WriteString("<" + name + ">")
+					open[name] = true
+				} else if !enable && open[name] {
+					builder.WriteString("</" + name + ">")
+					open[name] = false
+				}
+			case "r":
+				closeAll()
+			}
+		}
+	}
+	builder.WriteString(convertASSPlainText(text[last:]))
+	closeAll()
+
+	return builder.String()
+}
+
+// convertASSPlainText は上書きタグ以外のテキストの改行記号を変換してエスケープします
+func convertASSPlainText(text string) string {
+	replacer := strings.NewReplacer(`\N`, "\n", `\n`, " ", `\h`, " ")
+	return EscapeText(replacer.Replace(text))
+}
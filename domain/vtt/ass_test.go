@@ -0,0 +1,89 @@
+package vtt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseASS(t *testing.T) {
+	type cue struct {
+		start time.Duration
+		end   time.Duration
+		text  string
+		style string
+		line  int
+	}
+
+	const assHeader = "[Script Info]\nTitle: Test\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\n"
+
+	tests := []struct {
+		name  string
+		input string
+		want  []cue
+	}{
+		{
+			name: "ass with speaker and style",
+			input: assHeader +
+				"Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.50,Default,Alice,0,0,0,,Hello, world\n" +
+				"Comment: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,Skipped\n" +
+				"Dialogue: 0,0:00:05.00,0:00:06.00,*Sign,,0,0,0,,Sign text\n",
+			want: []cue{
+				{time.Second, 2500 * time.Millisecond, "<v Alice>Hello, world", "Default", 10},
+				{5 * time.Second, 6 * time.Second, "Sign text", "Sign", 12},
+			},
+		},
+		{
+			name: "ssa actor column",
+			input: "[Events]\n" +
+				"Format: Marked, Start, End, Style, Actor, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: Marked=0,0:00:01.00,0:00:02.00,Main,Tom & Jerry,0000,0000,0000,,Hi\n",
+			want: []cue{{time.Second, 2 * time.Second, "<v Tom &amp; Jerry>Hi", "Main", 3}},
+		},
+		{
+			name:  "default format and override tags",
+			input: "[Events]\nDialogue: 0,0:00:01.5,0:00:02.25,Default,,0,0,0,,{\\an8}{\\i1}Tom{\\i0} & {\\b1}Jerry\\Nnext\\hline\n",
+			want:  []cue{{1500 * time.Millisecond, 2250 * time.Millisecond, "<i>Tom</i> &amp; <b>Jerry\nnext line</b>", "Default", 2}},
+		},
+		{
+			name:  "reset closes open tags",
+			input: "[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\i1\\u1}a{\\r}b\n",
+			want:  []cue{{time.Second, 2 * time.Second, "<i><u>a</u></i>b", "Default", 2}},
+		},
+		{
+			name: "invalid time and drawing-only lines are dropped",
+			input: "[Events]\n" +
+				"Dialogue: 0,bad,0:00:02.00,Default,,0,0,0,,Bad\n" +
+				"Dialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,{\\p1}\n" +
+				"Dialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,Kept\n",
+			want: []cue{{3 * time.Second, 4 * time.Second, "Kept", "Default", 4}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseASS(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text || got.Style != want.style || got.Line != want.line {
+					t.Errorf("cue %d = {%v %v %q %q %d}, want %+v", i, got.StartTime, got.EndTime, got.Text, got.Style, got.Line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseASSMissingEvents(t *testing.T) {
+	_, err := ParseASS(strings.NewReader("[Script Info]\nTitle: Test\n"))
+	if !errors.Is(err, ErrInvalidASSEvents) {
+		t.Errorf("ParseASS() error = %v, want ErrInvalidASSEvents", err)
+	}
+}
@@ -0,0 +1,118 @@
+package vtt
+
+import (
+	"regexp"
+	"strings"
+)
+
+// キュー設定の値を検証するためのパターン
+var (
+	lineSettingRegex     = regexp.MustCompile(`^(-?\d+(\.\d+)?|\d+(\.\d+)?%)(,(start|center|end))?$`)
+	positionSettingRegex = regexp.MustCompile(`^\d+(\.\d+)?%(,(line-left|center|line-right))?$`)
+	sizeSettingRegex     = regexp.MustCompile(`^\d+(\.\d+)?%$`)
+)
+
+// CueSettings はキュータイミング行の後に続くキュー設定（position:, line:など）を表します
+// 値は仕様に沿って検証され、不正な値は無視されます
+type CueSettings struct {
+	Vertical string // 縦書きの方向（"rl" または "lr"）
+	Line     string // 行位置（例: "0", "-1", "50%,end"）
+	Position string // 水平位置（例: "10%", "50%,center"）
+	Size     string // キューボックスの幅（例: "80%"）
+	Align    string // テキストの配置（"start", "center", "end", "left", "right"）
+	Region   string // 参照するリージョンのID
+}
+
+// IsZero はキュー設定が何も指定されていない場合にtrueを返します
+func (c CueSettings) IsZero() bool {
+	return c == CueSettings{}
+}
+
+// String はキュー設定をWebVTTの設定リスト形式に変換します
+func (c CueSettings) String() string {
+	var settings []string
+	if c.Region != "" {
+		settings = append(settings, "region:"+c.Region)
+	}
+	if c.Vertical != "" {
+		settings = append(settings, "vertical:"+c.Vertical)
+	}
+	if c.Line != "" {
+		settings = append(settings, "line:"+c.Line)
+	}
+	if c.Position != "" {
+		settings = append(settings, "position:"+c.Position)
+	}
+	if c.Size != "" {
+		settings = append(settings, "size:"+c.Size)
+	}
+	if c.Align != "" {
+		settings = append(settings, "align:"+c.Align)
+	}
+	return strings.Join(settings, " ")
+}
+
+// parseCueSettings は空白区切りのキュー設定リストを解析します
+// 未知の設定や不正な値は仕様に従って無視します
+func parseCueSettings(input string) CueSettings {
+	var settings CueSettings
+	for _, setting := range strings.Fields(input) {
+		settings.apply(setting)
+	}
+	return settings
+}
+
+// invalidCueSettings は未知の設定や不正な値のために無視されるキュー設定を返します
+func invalidCueSettings(input string) []string {
+	var settings CueSettings
+	var invalid []string
+	for _, setting := range strings.Fields(input) {
+		if !settings.apply(setting) {
+			invalid = append(invalid, setting)
+		}
+	}
+	return invalid
+}
+
+// apply は"name:value"形式の1つの設定を反映し、有効な設定であればtrueを返します
+func (c *CueSettings) apply(setting string) bool {
+	name, value, found := strings.Cut(setting, ":")
+	if !found || name == "" || value == "" {
+		return false
+	}
+
+	switch name {
+	case "vertical":
+		if value == "rl" || value == "lr" {
+			c.Vertical = value
+			return true
+		}
+	case "line":
+		if lineSettingRegex.MatchString(value) {
+			c.Line = value
+			return true
+		}
+	case "position":
+		if positionSettingRegex.MatchString(value) {
+			c.Position = value
+			return true
+		}
+	case "size":
+		if sizeSettingRegex.MatchString(value) {
+			c.Size = value
+			return true
+		}
+	case "align":
+		switch value {
+		case "start", "center", "end", "left", "right":
+			c.Align = value
+			return true
+		}
+	case "region":
+		if !strings.Contains(value, "-->") {
+			c.Region = value
+			return true
+		}
+	}
+	return false
+}
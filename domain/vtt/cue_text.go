@@ -0,0 +1,252 @@
+package vtt
+
+import (
+	"html"
+	"strings"
+	"time"
+)
+
+// NodeType はキューテキスト内のノードの種類を表します
+type NodeType int
+
+const (
+	// RootNode はキューテキスト全体を表すルートノードです
+	RootNode NodeType = iota
+	// TextNode は文字参照をデコード済みのテキストです
+	TextNode
+	// ClassNode は<c>タグによるクラス指定のスパンです
+	ClassNode
+	// ItalicNode は<i>タグによる斜体のスパンです
+	ItalicNode
+	// BoldNode は<b>タグによる太字のスパンです
+	BoldNode
+	// UnderlineNode は<u>タグによる下線のスパンです
+	UnderlineNode
+	// RubyNode は<ruby>タグによるルビのスパンです
+	RubyNode
+	// RubyTextNode は<rt>タグによるルビテキストです
+	RubyTextNode
+	// VoiceNode は<v>タグによる話者のスパンです
+	VoiceNode
+	// LanguageNode は<lang>タグによる言語指定のスパンです
+	LanguageNode
+	// TimestampNode はキュー内の<00:00:01.500>形式のタイムスタンプです
+	TimestampNode
+)
+
+// cueTagTypes はタグ名とノードの種類の対応を表します
+var cueTagTypes = map[string]NodeType{
+	"c":    ClassNode,
+	"i":    ItalicNode,
+	"b":    BoldNode,
+	"u":    UnderlineNode,
+	"ruby": RubyNode,
+	"rt":   RubyTextNode,
+	"v":    VoiceNode,
+	"lang": LanguageNode,
+}
+
+// String はNodeTypeをタグ名に変換します
+func (t NodeType) String() string {
+	switch t {
+	case RootNode:
+		return "root"
+	case TextNode:
+		return "text"
+	case ClassNode:
+		return "c"
+	case ItalicNode:
+		return "i"
+	case BoldNode:
+		return "b"
+	case UnderlineNode:
+		return "u"
+	case RubyNode:
+		return "ruby"
+	case RubyTextNode:
+		return "rt"
+	case VoiceNode:
+		return "v"
+	case LanguageNode:
+		return "lang"
+	case TimestampNode:
+		return "timestamp"
+	default:
+		return "unknown"
+	}
+}
+
+// CueNode はキューテキストを解析したスパンのツリーの1ノードを表します
+type CueNode struct {
+	Type       NodeType
+	Classes    []string      // <c.loud>などのクラス名
+	Annotation string        // <v Alice>の話者名や<lang fr>の言語タグ
+	Text       string        // TextNodeのテキスト
+	Timestamp  time.Duration // TimestampNodeの時刻
+	Children   []*CueNode
+}
+
+// IsEmphasis はノードが強調（<b>, <i>, <u>）を表すかどうかを返します
+func (n *CueNode) IsEmphasis() bool {
+	return n.Type == BoldNode || n.Type == ItalicNode || n.Type == UnderlineNode
+}
+
+// PlainText はマークアップを除いた読み上げ用のテキストを返します
+// ルビテキストとタイムスタンプは含みません
+func (n *CueNode) PlainText() string {
+	var builder strings.Builder
+	n.writePlainText(&builder)
+	return builder.String()
+}
+
+// writePlainText はテキストノードの内容を順に書き込みます
+func (n *CueNode) writePlainText(builder *strings.Builder) {
+	switch n.Type {
+	case TextNode:
+		builder.WriteString(n.Text)
+		return
+	case RubyTextNode:
+		return
+	}
+	for _, child := range n.Children {
+		child.writePlainText(builder)
+	}
+}
+
+// Walk はノードとその子孫を深さ優先で訪問します
+// fnがfalseを返した場合はそのノードの子孫を訪問しません
+func (n *CueNode) Walk(fn func(node *CueNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Children {
+		child.Walk(fn)
+	}
+}
+
+// CueText は字幕のテキストを解析したノードツリーを返します
+func (s Subtitle) CueText() *CueNode {
+	return ParseCueText(s.Text)
+}
+
+// PlainText は字幕のテキストからマークアップを除いたテキストを返します
+func (s Subtitle) PlainText() string {
+	return ParseCueText(s.Text).PlainText()
+}
+
+// Speaker は字幕の最初の<v>タグで指定された話者名を返します（指定がない場合は空文字）
+func (s Subtitle) Speaker() string {
+	speaker := ""
+	ParseCueText(s.Text).Walk(func(node *CueNode) bool {
+		if speaker != "" {
+			return false
+		}
+		if node.Type == VoiceNode && node.Annotation != "" {
+			speaker = node.Annotation
+			return false
+		}
+		return true
+	})
+	return speaker
+}
+
+// ParseCueText はWebVTTのキューテキストを解析し、ノードツリーを返します
+// 未知のタグや対応しない終了タグは仕様に従って無視します
+func ParseCueText(text string) *CueNode {
+	root := &CueNode{Type: RootNode}
+	stack := []*CueNode{root}
+	current := func() *CueNode { return stack[len(stack)-1] }
+
+	for len(text) > 0 {
+		// タグまでのテキストをテキストノードとして追加
+		tagStart := strings.IndexByte(text, '<')
+		if tagStart != 0 {
+			run := text
+			if tagStart > 0 {
+				run = text[:tagStart]
+			}
+			appendText(current(), html.UnescapeString(run))
+			text = text[len(run):]
+			continue
+		}
+
+		// タグは'>'で終わる（閉じられていない場合は末尾まで）
+		tagEnd := strings.IndexByte(text, '>')
+		var tag string
+		if tagEnd < 0 {
+			tag, text = text[1:], ""
+		} else {
+			tag, text = text[1:tagEnd], text[tagEnd+1:]
+		}
+
+		// 終了タグ
+		if strings.HasPrefix(tag, "/") {
+			name, _, _ := strings.Cut(strings.TrimSpace(tag[1:]), ".")
+			nodeType, ok := cueTagTypes[name]
+			if !ok || len(stack) == 1 {
+				continue
+			}
+			switch {
+			case current().Type == nodeType:
+				stack = stack[:len(stack)-1]
+			case current().Type == RubyTextNode && nodeType == RubyNode && len(stack) > 2:
+				stack = stack[:len(stack)-2]
+			}
+			continue
+		}
+
+		// タイムスタンプタグ
+		if timestamp, err := parseTimestamp(strings.TrimSpace(tag)); err == nil {
+			current().Children = append(current().Children, &CueNode{Type: TimestampNode, Timestamp: timestamp})
+			continue
+		}
+
+		// 開始タグ（名前.クラス1.クラス2 注釈）
+		nameAndClasses, annotation := tag, ""
+		if i := strings.IndexAny(tag, " \t\n"); i >= 0 {
+			nameAndClasses, annotation = tag[:i], tag[i+1:]
+		}
+		parts := strings.Split(nameAndClasses, ".")
+		nodeType, ok := cueTagTypes[parts[0]]
+		if !ok {
+			continue
+		}
+		// <rt>は<ruby>の中でのみ有効
+		if nodeType == RubyTextNode && current().Type != RubyNode {
+			continue
+		}
+
+		node := &CueNode{Type: nodeType}
+		for _, class := range parts[1:] {
+			if class != "" {
+				node.Classes = append(node.Classes, class)
+			}
+		}
+		if nodeType == VoiceNode || nodeType == LanguageNode {
+			node.Annotation = strings.Join(strings.Fields(html.UnescapeString(annotation)), " ")
+		}
+
+		current().Children = append(current().Children, node)
+		stack = append(stack, node)
+	}
+
+	return root
+}
+
+// appendText は直前の子がテキストノードであれば連結し、そうでなければ新しいテキストノードを追加します
+func appendText(parent *CueNode, text string) {
+	if text == "" {
+		return
+	}
+	if n := len(parent.Children); n > 0 && parent.Children[n-1].Type == TextNode {
+		parent.Children[n-1].Text += text
+		return
+	}
+	parent.Children = append(parent.Children, &CueNode{Type: TextNode, Text: text})
+}
+
+// EscapeText はプレーンテキストをキューテキストとして安全に埋め込めるようにエスケープします
+func EscapeText(text string) string {
+	replacer := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	return replacer.Replace(text)
+}
@@ -0,0 +1,102 @@
+package vtt
+
+import (
+	"strings"
+	"testing"
+)
+
+// describeNode はノードツリーを比較しやすい文字列に変換します
+func describeNode(node *CueNode) string {
+	switch node.Type {
+	case TextNode:
+		return "'" + node.Text + "'"
+	case TimestampNode:
+		return "<" + FormatTimestamp(node.Timestamp) + ">"
+	}
+
+	var builder strings.Builder
+	builder.WriteString(node.Type.String())
+	for _, class := range node.Classes {
+		builder.WriteString("." + class)
+	}
+	if node.Annotation != "" {
+		builder.WriteString(" " + node.Annotation)
+	}
+	builder.WriteString("(")
+	for i, child := range node.Children {
+		if i > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(describeNode(child))
+	}
+	builder.WriteString(")")
+	return builder.String()
+}
+
+func TestParseCueText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "Hello", "root('Hello')"},
+		{"character references", "Tom &amp; Jerry &lt;3 &nbsp;!", "root('Tom & Jerry <3 \u00a0!')"},
+		{"nested spans", "<b>bold <i>both</i></b> none", "root(b('bold ' i('both')) ' none')"},
+		{"classes", "<c.loud.red>Hey</c>", "root(c.loud.red('Hey'))"},
+		{"voice with classes", "<v.first Alice  Smith>Hi</v>", "root(v.first Alice Smith('Hi'))"},
+		{"escaped voice annotation", "<v Tom &amp; Jerry>Hi", "root(v Tom & Jerry('Hi'))"},
+		{"language", "<lang fr>Bonjour</lang>", "root(lang fr('Bonjour'))"},
+		{"ruby", "<ruby>漢<rt>かん</rt>字<rt>じ</ruby>", "root(ruby('漢' rt('かん') '字' rt('じ')))"},
+		{"rt outside ruby is ignored", "<rt>a</rt>b", "root('ab')"},
+		{"timestamps", "One <00:01.500>two <01:00:02.000>three", "root('One ' <00:00:01.500> 'two ' <01:00:02.000> 'three')"},
+		{"unknown tag is ignored", "<font color=red>Hi</font>", "root('Hi')"},
+		{"unmatched end tag is ignored", "a</b>b<i>c</b>d", "root('ab' i('cd'))"},
+		{"unclosed tag", "Hello <i", "root('Hello ' i())"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeNode(ParseCueText(tt.input)); got != tt.want {
+				t.Errorf("ParseCueText(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubtitleTextAccessors(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantPlain   string
+		wantSpeaker string
+	}{
+		{"markup removed", "<b>Hello</b> <i>world</i>", "Hello world", ""},
+		{"ruby text skipped", "<ruby>漢字<rt>かんじ</rt></ruby>です", "漢字です", ""},
+		{"first speaker", "<v Alice>Hi</v> <v Bob>there</v>", "Hi there", "Alice"},
+		{"nested speaker", "<i><v Bob>Hey</v></i>", "Hey", "Bob"},
+		{"empty voice annotation", "<v>Hey", "Hey", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtitle := Subtitle{Text: tt.text}
+			if got := subtitle.PlainText(); got != tt.wantPlain {
+				t.Errorf("PlainText() = %q, want %q", got, tt.wantPlain)
+			}
+			if got := subtitle.Speaker(); got != tt.wantSpeaker {
+				t.Errorf("Speaker() = %q, want %q", got, tt.wantSpeaker)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	input := "a < b && c > d"
+	escaped := EscapeText(input)
+	if escaped != "a &lt; b &amp;&amp; c &gt; d" {
+		t.Errorf("EscapeText(%q) = %q", input, escaped)
+	}
+	if got := ParseCueText(escaped).PlainText(); got != input {
+		t.Errorf("PlainText(EscapeText(%q)) = %q", input, got)
+	}
+}
@@ -0,0 +1,227 @@
+package vtt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// 文字コードの自動判別に使用する定数
+const (
+	// CharsetAuto は文字コードを内容から判別することを表します
+	CharsetAuto = "auto"
+	// minCharsetScore は東アジアの文字コードと判定するために必要な最低のスコアです
+	// スコアは文字のうちその言語でよく使われる文字の割合のため、半分以上を求めます
+	minCharsetScore = 0.5
+)
+
+// byteOrderMark はUTF-8のBOMを表します
+var byteOrderMark = []byte{0xEF, 0xBB, 0xBF}
+
+// ひらがなとカタカナの範囲を判定するための下限と上限
+const (
+	kanaStart = '぀'
+	kanaEnd   = 'ヿ'
+)
+
+// charsetCandidate はBOMがなくUTF-8でもないデータに対して試す文字コードと、その文字コードらしさの評価方法を表します
+// 評価はすべての候補で同じ尺度（0から1のスコア）で行います
+type charsetCandidate struct {
+	name     string
+	encoding encoding.Encoding
+	score    func(text string) float64
+}
+
+// charsetCandidates は自動判別で試す文字コードの一覧です（同じスコアの場合は先の候補を優先します）
+// 韓国語のEUC-KRはGB18030としても一級漢字に復号されるため、中国語より先に試します
+var charsetCandidates = []charsetCandidate{
+	{"shift_jis", japanese.ShiftJIS, japaneseScore},
+	{"euc-jp", japanese.EUCJP, japaneseScore},
+	{"euc-kr", korean.EUCKR, koreanScore},
+	{"gb18030", simplifiedchinese.GB18030, chineseScore},
+}
+
+// NewDecodingReader はリーダーの内容をUTF-8に変換し、BOMを取り除いたリーダーを返します
+// charsetが空または"auto"の場合は内容から文字コードを判別します
+func NewDecodingReader(r io.Reader, charset string) (io.Reader, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if charset == "" || strings.EqualFold(charset, CharsetAuto) {
+		charset = DetectCharset(data)
+	}
+
+	decoded, err := decodeBytes(data, charset)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytes.NewReader(bytes.TrimPrefix(decoded, byteOrderMark)), nil
+}
+
+// DetectCharset はデータの文字コードを判別し、文字コード名を返します
+// BOM、UTF-8としての妥当性、東アジアの文字コードでの復号結果の順に判定し、
+// いずれにも当てはまらない場合はWindows-1252として扱います
+func DetectCharset(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, byteOrderMark):
+		return "utf-8"
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return "utf-16le"
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return "utf-16be"
+	case utf8.Valid(data):
+		return "utf-8"
+	}
+
+	best, bestScore := "", 0.0
+	for _, candidate := range charsetCandidates {
+		decoded, err := candidate.encoding.NewDecoder().Bytes(data)
+		if err != nil || bytes.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		if score := candidate.score(string(decoded)); score > bestScore {
+			best, bestScore = candidate.name, score
+		}
+	}
+	if bestScore >= minCharsetScore {
+		return best
+	}
+
+	return "windows-1252"
+}
+
+// decodeBytes は指定された文字コードのデータをUTF-8に変換します
+func decodeBytes(data []byte, charset string) ([]byte, error) {
+	var enc encoding.Encoding
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8":
+		return data, nil
+	case "utf-16le":
+		enc = xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM)
+	case "utf-16be":
+		enc = xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM)
+	case "windows-1252", "cp1252":
+		enc = charmap.Windows1252
+	default:
+		var err error
+		enc, err = htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("未対応の文字コードです: %s", charset)
+		}
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%sからの文字コード変換に失敗しました: %w", charset, err)
+	}
+	return decoded, nil
+}
+
+// japaneseScore は文字のうち、ひらがな、カタカナ、第1水準漢字が占める割合を返します
+// 中国語の文章も漢字だけで構成されるため、かなを含まない場合は0を返します
+func japaneseScore(text string) float64 {
+	if !strings.ContainsFunc(text, isKana) {
+		return 0
+	}
+	kanji := commonCharacters().kanji
+	return letterRatio(text, func(r rune) bool { return isKana(r) || kanji[r] })
+}
+
+// koreanScore は文字のうち、KS X 1001の現代ハングルが占める割合を返します
+func koreanScore(text string) float64 {
+	hangul := commonCharacters().hangul
+	return letterRatio(text, func(r rune) bool { return hangul[r] })
+}
+
+// chineseScore は文字のうち、GB 2312の一級漢字が占める割合を返します
+func chineseScore(text string) float64 {
+	hanzi := commonCharacters().hanzi
+	return letterRatio(text, func(r rune) bool { return hanzi[r] })
+}
+
+// isKana は文字がひらがなまたは全角のカタカナかどうかを判定します
+func isKana(r rune) bool {
+	return r >= kanaStart && r <= kanaEnd
+}
+
+// letterRatio は非ASCIIの文字（記号を除く）のうち条件に当てはまる文字の割合を返します
+// 全角の句読点などの記号は複数の文字コードに共通するため数えません
+func letterRatio(text string, match func(r rune) bool) float64 {
+	total, matched := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf || !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		if match(r) {
+			matched++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(matched) / float64(total)
+}
+
+// newLineScanner は先頭のBOMを取り除き、改行コード（LF, CRLF, CR）を区別せずに行を読み込むスキャナーを作成します
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	first := true
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if first {
+			if len(data) < len(byteOrderMark) && !atEOF && bytes.HasPrefix(byteOrderMark, data) {
+				return 0, nil, nil
+			}
+			first = false
+			if bytes.HasPrefix(data, byteOrderMark) {
+				return len(byteOrderMark), nil, nil
+			}
+		}
+		return scanLines(data, atEOF)
+	})
+	return scanner
+}
+
+// scanLines はLF, CRLF, CRのいずれかで区切られた1行を返す分割関数です
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// CRの次の文字を確認するために、データの末尾であればさらに読み込む
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
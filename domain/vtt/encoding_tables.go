@@ -0,0 +1,65 @@
+package vtt
+
+import (
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// charsetRows は2バイトの文字コードで、よく使われる文字を収録した区間（先頭バイトの範囲）を表します
+// 2バイト目はいずれの文字コードも0xA1から0xFEまでです
+type charsetRows struct {
+	encoding encoding.Encoding
+	first    byte
+	last     byte
+}
+
+// 各文字コードの規格で使用頻度の高い文字として定められた区間
+var (
+	// jisLevel1Rows はJIS X 0208の第1水準漢字（EUC-JPの0xB0A1から0xCFD3）です
+	jisLevel1Rows = charsetRows{japanese.EUCJP, 0xB0, 0xCF}
+	// ksHangulRows はKS X 1001の現代ハングルの音節（EUC-KRの0xB0A1から0xC8FE）です
+	ksHangulRows = charsetRows{korean.EUCKR, 0xB0, 0xC8}
+	// gbLevel1Rows はGB 2312の一級漢字（GB18030の0xB0A1から0xD7F9）です
+	gbLevel1Rows = charsetRows{simplifiedchinese.GBK, 0xB0, 0xD7}
+)
+
+// commonCharacterSets は文字コードの自動判別に使用する、よく使われる文字の集合です
+type commonCharacterSets struct {
+	kanji  map[rune]bool // 日本語でよく使われる漢字
+	hangul map[rune]bool // 韓国語でよく使われるハングル
+	hanzi  map[rune]bool // 中国語でよく使われる漢字
+}
+
+// commonCharacters は各文字コードの区間を復号して、よく使われる文字の集合を作成します
+// 文字の一覧をソースコードに持たず、文字コードの変換表から初回の使用時に一度だけ作成します
+var commonCharacters = sync.OnceValue(func() commonCharacterSets {
+	return commonCharacterSets{
+		kanji:  jisLevel1Rows.characters(),
+		hangul: ksHangulRows.characters(),
+		hanzi:  gbLevel1Rows.characters(),
+	}
+})
+
+// characters は区間に収録された文字の集合を返します
+func (rows charsetRows) characters() map[rune]bool {
+	set := make(map[rune]bool, (int(rows.last)-int(rows.first)+1)*94)
+	decoder := rows.encoding.NewDecoder()
+	for first := int(rows.first); first <= int(rows.last); first++ {
+		for second := 0xA1; second <= 0xFE; second++ {
+			decoded, err := decoder.Bytes([]byte{byte(first), byte(second)})
+			if err != nil {
+				continue
+			}
+			r, size := utf8.DecodeRune(decoded)
+			if r != utf8.RuneError && size == len(decoded) {
+				set[r] = true
+			}
+		}
+	}
+	return set
+}
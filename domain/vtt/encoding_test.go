@@ -0,0 +1,137 @@
+package vtt
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+)
+
+// 文字コードの判別に使用する字幕の例
+const (
+	japaneseSample = "1\r\n00:00:01,000 --> 00:00:04,000\r\n本日は東京駅から新幹線で大阪へ向かいます。\r\n\r\n" +
+		"2\r\n00:00:04,500 --> 00:00:07,000\r\n少々お待ちください。\r\n"
+	koreanSample = "1\r\n00:00:01,000 --> 00:00:04,000\r\n안녕하세요. 오늘은 서울에서 부산까지 기차를 타고 갑니다.\r\n\r\n" +
+		"2\r\n00:00:04,500 --> 00:00:07,000\r\n잠시만 기다려 주세요.\r\n"
+	chineseSample = "1\r\n00:00:01,000 --> 00:00:04,000\r\n大家好，今天我们从北京坐火车去上海。\r\n\r\n" +
+		"2\r\n00:00:04,500 --> 00:00:07,000\r\n请稍等一下，我们马上就到了。\r\n"
+	latinSample = "1\r\n00:00:01,000 --> 00:00:04,000\r\nCafé au lait, s'il vous plaît. Déjà vu!\r\n"
+)
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding encoding.Encoding
+		want     string
+	}{
+		{"utf-8", japaneseSample, encoding.Nop, "utf-8"},
+		{"utf-8 with bom", "\uFEFF" + koreanSample, encoding.Nop, "utf-8"},
+		{"utf-16le with bom", japaneseSample, xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM), "utf-16le"},
+		{"utf-16be with bom", chineseSample, xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM), "utf-16be"},
+		{"shift_jis", japaneseSample, japanese.ShiftJIS, "shift_jis"},
+		{"euc-jp", japaneseSample, japanese.EUCJP, "euc-jp"},
+		{"euc-kr", koreanSample, korean.EUCKR, "euc-kr"},
+		{"gb18030", chineseSample, simplifiedchinese.GB18030, "gb18030"},
+		{"windows-1252", latinSample, charmap.Windows1252, "windows-1252"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.encoding.NewEncoder().Bytes([]byte(tt.text))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectCharset(data); got != tt.want {
+				t.Errorf("DetectCharset() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharsetScores(t *testing.T) {
+	tests := []struct {
+		name     string
+		score    func(string) float64
+		sample   string
+		wantHigh bool
+	}{
+		{"japanese", japaneseScore, japaneseSample, true},
+		{"japanese on chinese", japaneseScore, chineseSample, false},
+		{"japanese on korean", japaneseScore, koreanSample, false},
+		{"korean", koreanScore, koreanSample, true},
+		{"korean on japanese", koreanScore, japaneseSample, false},
+		{"korean on chinese", koreanScore, chineseSample, false},
+		{"chinese", chineseScore, chineseSample, true},
+		{"chinese on korean", chineseScore, koreanSample, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.score(tt.sample)
+			if tt.wantHigh && got < 0.9 {
+				t.Errorf("score = %.2f, want at least 0.9", got)
+			}
+			if !tt.wantHigh && got >= minCharsetScore {
+				t.Errorf("score = %.2f, want below %.2f", got, minCharsetScore)
+			}
+		})
+	}
+}
+
+func TestNewDecodingReader(t *testing.T) {
+	data, err := japanese.ShiftJIS.NewEncoder().Bytes([]byte(japaneseSample))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		charset string
+	}{
+		{"auto", CharsetAuto},
+		{"explicit", "shift_jis"},
+		{"alias", "Shift-JIS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, err := NewDecodingReader(strings.NewReader(string(data)), tt.charset)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file, err := ParseSRT(reader)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != 2 || file.Subtitles[1].Text != "少々お待ちください。" {
+				t.Errorf("subtitles = %+v", file.Subtitles)
+			}
+		})
+	}
+
+	if _, err := NewDecodingReader(strings.NewReader("x"), "no-such-charset"); err == nil {
+		t.Error("expected error for unknown charset")
+	}
+}
+
+func TestNewLineScanner(t *testing.T) {
+	input := "\uFEFFWEBVTT\r\n\r\nA\rB\nC"
+
+	scanner := newLineScanner(io.MultiReader(strings.NewReader(input[:2]), strings.NewReader(input[2:])))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+
+	want := []string{"WEBVTT", "", "A", "B", "C"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
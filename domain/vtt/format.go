@@ -0,0 +1,170 @@
+package vtt
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnknownFormat は字幕ファイルの形式を判別できない場合のエラーです
+var ErrUnknownFormat = errors.New("unknown subtitle format")
+
+// sniffSize は形式の判別に使用する先頭のバイト数です
+const sniffSize = 1024
+
+// Format は字幕ファイルの形式を表します
+type Format int
+
+const (
+	// FormatUnknown は判別できない形式を表します
+	FormatUnknown Format = iota
+	// FormatVTT はWebVTT形式を表します
+	FormatVTT
+	// FormatSRT はSubRip形式を表します
+	FormatSRT
+	// FormatTTML はTTML形式（DFXP, IMSCを含む）を表します
+	FormatTTML
+	// FormatASS はAdvanced SubStation Alpha/SubStation Alpha形式を表します
+	FormatASS
+	// FormatTranscript はWhisperなどの音声認識ツールが出力するJSONトランスクリプトを表します
+	FormatTranscript
+	// FormatSBV はYouTubeのSBV形式を表します
+	FormatSBV
+)
+
+// String はFormatを文字列に変換します
+func (f Format) String() string {
+	switch f {
+	case FormatVTT:
+		return "vtt"
+	case FormatSRT:
+		return "srt"
+	case FormatTTML:
+		return "ttml"
+	case FormatASS:
+		return "ass"
+	case FormatTranscript:
+		return "json"
+	case FormatSBV:
+		return "sbv"
+	default:
+		return "unknown"
+	}
+}
+
+// FormatFromExtension はファイルの拡張子から字幕の形式を判定します
+func FormatFromExtension(filePath string) Format {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".vtt", ".webvtt":
+		return FormatVTT
+	case ".srt":
+		return FormatSRT
+	case ".ttml", ".dfxp", ".xml":
+		return FormatTTML
+	case ".ass", ".ssa":
+		return FormatASS
+	case ".json":
+		return FormatTranscript
+	case ".sbv":
+		return FormatSBV
+	default:
+		return FormatUnknown
+	}
+}
+
+// DetectFormat はファイルの先頭部分の内容から字幕の形式を判定します
+func DetectFormat(head []byte) Format {
+	head = bytes.TrimLeft(bytes.TrimPrefix(head, byteOrderMark), " \t\r\n")
+	head = bytes.ReplaceAll(head, []byte("\r\n"), []byte("\n"))
+
+	if bytes.HasPrefix(head, []byte(vttHeader)) {
+		return FormatVTT
+	}
+
+	// SRTは番号行の次にタイミング行が続く
+	lines := strings.SplitN(string(head), "\n", 3)
+	if len(lines) >= 2 && isSRTIndex(lines[0]) && srtTimingRegex.MatchString(lines[1]) {
+		return FormatSRT
+	}
+
+	// ASS/SSAは[Script Info]セクションから始まる
+	if bytes.HasPrefix(head, []byte("[Script Info]")) {
+		return FormatASS
+	}
+
+	// JSONトランスクリプトはオブジェクトまたはオブジェクトの配列（"["の後の空白や改行は無視する）
+	if bytes.HasPrefix(head, []byte("{")) {
+		return FormatTranscript
+	}
+	if rest, found := bytes.CutPrefix(head, []byte("[")); found && bytes.HasPrefix(bytes.TrimLeft(rest, " \t\r\n"), []byte("{")) {
+		return FormatTranscript
+	}
+
+	// SBVはタイミング行から始まる
+	if line, _, _ := strings.Cut(string(head), "\n"); sbvTimingRegex.MatchString(line) {
+		return FormatSBV
+	}
+
+	// TTMLはtt要素をルートとするXML
+	if bytes.HasPrefix(head, []byte("<")) && bytes.Contains(head, []byte("<tt")) &&
+		(bytes.Contains(head, []byte("ns/ttml")) || bytes.Contains(head, []byte("ttaf1"))) {
+		return FormatTTML
+	}
+
+	return FormatUnknown
+}
+
+// ParseFormat は形式名（"vtt", "srt", "ttml", "ass", "json", "sbv"）をFormatに変換します
+// 空文字と"auto"はFormatUnknown（内容から判別）として扱います
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return FormatUnknown, nil
+	case "vtt", "webvtt":
+		return FormatVTT, nil
+	case "srt":
+		return FormatSRT, nil
+	case "ttml", "dfxp", "imsc", "xml":
+		return FormatTTML, nil
+	case "ass", "ssa":
+		return FormatASS, nil
+	case "json", "whisper":
+		return FormatTranscript, nil
+	case "sbv":
+		return FormatSBV, nil
+	default:
+		return FormatUnknown, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+}
+
+// Parse はリーダーから指定された形式の字幕データを解析します
+// formatがFormatUnknownの場合は内容から形式を判別します
+func Parse(r io.Reader, format Format) (*VTTFile, error) {
+	reader := bufio.NewReaderSize(r, sniffSize)
+
+	if format == FormatUnknown {
+		head, _ := reader.Peek(sniffSize)
+		format = DetectFormat(head)
+	}
+
+	switch format {
+	case FormatVTT:
+		return ParseVTT(reader)
+	case FormatSRT:
+		return ParseSRT(reader)
+	case FormatTTML:
+		return ParseTTML(reader)
+	case FormatASS:
+		return ParseASS(reader)
+	case FormatTranscript:
+		return ParseTranscript(reader)
+	case FormatSBV:
+		return ParseSBV(reader)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
@@ -0,0 +1,54 @@
+package vtt
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Format
+	}{
+		{"vtt", "WEBVTT\n\n", FormatVTT},
+		{"vtt with bom", "\uFEFFWEBVTT\n", FormatVTT},
+		{"srt", "1\r\n00:00:01,000 --> 00:00:02,000\r\nA\r\n", FormatSRT},
+		{"ass", "[Script Info]\nTitle: x\n", FormatASS},
+		{"json object", `{"segments": []}`, FormatTranscript},
+		{"json array", `[{"start": 0}]`, FormatTranscript},
+		{"json array with newline", "[\n  {\"start\": 0}]", FormatTranscript},
+		{"sbv", "0:00:01.000,0:00:02.000\nA\n", FormatSBV},
+		{"ttml", `<?xml version="1.0"?><tt xmlns="http://www.w3.org/ns/ttml">`, FormatTTML},
+		{"unknown", "hello", FormatUnknown},
+		{"not a transcript array", "[1, 2]", FormatUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat([]byte(tt.input)); got != tt.want {
+				t.Errorf("DetectFormat(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Format
+		wantErr bool
+	}{
+		{"auto", FormatUnknown, false},
+		{"WebVTT", FormatVTT, false},
+		{"dfxp", FormatTTML, false},
+		{"ssa", FormatASS, false},
+		{"json", FormatTranscript, false},
+		{"sbv", FormatSBV, false},
+		{"docx", FormatUnknown, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFormat(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseFormat(%q) = %v, %v, want %v (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
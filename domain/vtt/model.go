@@ -3,6 +3,7 @@ package vtt
 import (
 	"bufio"
 	"errors"
+	"io"
 	"os"
 	"regexp"
 	"strconv"
@@ -19,21 +20,55 @@ var (
 
 // 定数定義
 const (
-	vttHeader        = "WEBVTT"
-	timestampPattern = `(\d{2}:\d{2}:\d{2}\.\d{3}) --> (\d{2}:\d{2}:\d{2}\.\d{3})`
+	vttHeader = "WEBVTT"
+	// timestampPattern はWebVTTのタイムスタンプ（[HH...:]MM:SS.mmm）を表します。時間は2桁以上を許容します
+	timestampPattern = `(?:(\d{2,}):)?([0-5]\d):([0-5]\d)\.(\d{3})`
+	// cueTimingPattern はキュータイミング行（開始 --> 終了 [設定...]）を表します
+	cueTimingPattern = `^(` + timestampPattern + `)[ \t]+-->[ \t]+(` + timestampPattern + `)(?:[ \t]+(.*))?$`
+)
+
+// 正規表現はパッケージ初期化時に一度だけコンパイルします
+var (
+	timestampRegex = regexp.MustCompile(`^` + timestampPattern + `$`)
+	cueTimingRegex = regexp.MustCompile(cueTimingPattern)
 )
 
 // Subtitle はVTTファイル内の単一の字幕エントリーを表します
 type Subtitle struct {
-	ID        string
+	ID        string // キュー識別子（タイミング行の前の行、省略可能）
 	StartTime time.Duration
 	EndTime   time.Duration
 	Text      string
+	Settings  CueSettings // タイミング行に続くキュー設定
+	Style     string      // 変換元の形式で指定されたスタイル名（ASS/SSAのStyle列など）
+	Line      int         // 変換元のデータでタイミングを記述した行の行番号（1始まり、不明な場合は0）
+}
+
+// Note はNOTEブロック（コメント）を表します
+type Note struct {
+	Text     string // NOTEキーワードを除いたコメント本文
+	CueIndex int    // このコメントより前に現れた字幕の数
+}
+
+// Style はSTYLEブロックに記述されたスタイルシートを表します
+type Style struct {
+	CSS string
 }
 
 // VTTFile は解析されたVTTファイルを表します
 type VTTFile struct {
-	Subtitles []Subtitle
+	Header      string   // ヘッダー行の"WEBVTT"に続くテキスト（例: "- Translation"）
+	HeaderLines []string // ヘッダー行に続く最初の空行までの行（例: "Kind: captions"）
+	Subtitles   []Subtitle
+	Notes       []Note   // NOTEブロック（音声合成の対象外）
+	Styles      []Style  // STYLEブロック
+	Regions     []Region // REGIONブロック
+}
+
+// block は空行で区切られたVTTファイル内の1ブロックを表します
+type block struct {
+	line  int // ブロック先頭の行番号（1始まり）
+	lines []string
 }
 
 // ParseVTTFile はVTTファイルを解析し、VTTFile構造体を返します
@@ -50,119 +85,228 @@ func ParseVTTFile(filePath string) (vttFile *VTTFile, err error) {
 		}
 	}()
 
-	scanner := bufio.NewScanner(file)
+	return ParseVTT(file)
+}
+
+// ParseVTT はリーダーからWebVTT形式のデータを解析します
+func ParseVTT(r io.Reader) (*VTTFile, error) {
+	scanner := newLineScanner(r)
 
 	// ファイルが"WEBVTT"で始まるかどうかを確認
-	if !scanner.Scan() || !strings.HasPrefix(scanner.Text(), vttHeader) {
+	if !scanner.Scan() || !isVTTHeader(scanner.Text()) {
 		return nil, ErrInvalidVTTHeader
 	}
+	headerLine := scanner.Text()
 
-	subtitles, err := parseSubtitles(scanner)
+	headerLines, blocks, err := readBlocks(scanner, 1)
 	if err != nil {
 		return nil, err
 	}
 
-	return &VTTFile{Subtitles: subtitles}, nil
+	vttFile := parseBlocks(blocks)
+	vttFile.Header = parseHeaderText(headerLine)
+	vttFile.HeaderLines = headerLines
+	return vttFile, nil
+}
+
+// parseHeaderText はヘッダー行の"WEBVTT"に続くテキストを返します
+func parseHeaderText(headerLine string) string {
+	rest := strings.TrimPrefix(strings.TrimPrefix(headerLine, "\uFEFF"), vttHeader)
+	return strings.TrimLeft(rest, " \t")
 }
 
-// parseSubtitles はヘッダーが処理された後にスキャナーから字幕を抽出します
-func parseSubtitles(scanner *bufio.Scanner) ([]Subtitle, error) {
-	timestampRegex := regexp.MustCompile(timestampPattern)
-	subtitles := []Subtitle{}
+// isVTTHeader は行がWebVTTのヘッダー行かどうかを判定します
+// 先頭のBOMは無視し、"WEBVTT"の後には空白、タブ、または行末が続く必要があります
+func isVTTHeader(line string) bool {
+	rest, found := strings.CutPrefix(strings.TrimPrefix(line, "\uFEFF"), vttHeader)
+	return found && (rest == "" || rest[0] == ' ' || rest[0] == '\t')
+}
 
-	var currentSubtitle *Subtitle
-	var textLines []string
+// readBlocks はヘッダー行の後に続く行を空行区切りのブロックに分割します
+// headerLine はすでに読み込まれたヘッダー行の行番号です
+// ヘッダー行に続く空行までの行はブロックとは別に返します
+func readBlocks(scanner *bufio.Scanner, headerLine int) ([]string, []block, error) {
+	var headerLines []string
+	var blocks []block
+	var current *block
+	lineNumber := headerLine
+	inHeader := true
 
 	for scanner.Scan() {
 		line := scanner.Text()
+		lineNumber++
 
-		// 空行は字幕エントリーの区切りとして扱う
+		// 空行はブロックの区切りとして扱う
 		if line == "" {
-			if currentSubtitle != nil && len(textLines) > 0 {
-				currentSubtitle.Text = strings.Join(textLines, "\n")
-				subtitles = append(subtitles, *currentSubtitle)
-				currentSubtitle = nil
-				textLines = nil
+			inHeader = false
+			if current != nil {
+				blocks = append(blocks, *current)
+				current = nil
 			}
 			continue
 		}
 
-		// タイムスタンプ行のチェック
-		matches := timestampRegex.FindStringSubmatch(line)
-		if len(matches) == 3 {
-			// 前の字幕が処理中なら保存する
-			if currentSubtitle != nil && len(textLines) > 0 {
-				currentSubtitle.Text = strings.Join(textLines, "\n")
-				subtitles = append(subtitles, *currentSubtitle)
-			}
+		// ヘッダー行に続く空行までの行はヘッダーの一部として扱う
+		// ただし空行なしでタイミング行が続く場合はそこからキューとして扱う
+		if inHeader && !strings.Contains(line, "-->") {
+			headerLines = append(headerLines, line)
+			continue
+		}
+		inHeader = false
 
-			startTime, err := parseTimestamp(matches[1])
-			if err != nil {
-				return nil, err
-			}
+		// キューテキストの途中に"-->"を含む行が現れた場合は新しいブロックとして扱う
+		if current != nil && strings.Contains(line, "-->") && !isMetadataBlock(current.lines[0]) &&
+			(strings.Contains(current.lines[0], "-->") || len(current.lines) > 1) {
+			blocks = append(blocks, *current)
+			current = nil
+		}
 
-			endTime, err := parseTimestamp(matches[2])
-			if err != nil {
-				return nil, err
-			}
+		if current == nil {
+			current = &block{line: lineNumber}
+		}
+		current.lines = append(current.lines, line)
+	}
+
+	if current != nil {
+		blocks = append(blocks, *current)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	return headerLines, blocks, nil
+}
+
+// parseBlocks はブロックを種類ごとに解析してVTTFile構造体を組み立てます
+func parseBlocks(blocks []block) *VTTFile {
+	vttFile := &VTTFile{Subtitles: []Subtitle{}}
+
+	for _, b := range blocks {
+		first := b.lines[0]
+
+		switch {
+		case isBlockKeyword(first, "NOTE"):
+			lines := append([]string{strings.TrimLeft(strings.TrimPrefix(first, "NOTE"), " \t")}, b.lines[1:]...)
+			vttFile.Notes = append(vttFile.Notes, Note{
+				Text:     strings.TrimLeft(strings.Join(lines, "\n"), "\n"),
+				CueIndex: len(vttFile.Subtitles),
+			})
 
-			currentSubtitle = &Subtitle{
-				StartTime: startTime,
-				EndTime:   endTime,
+		case isBlockKeyword(first, "STYLE") && len(vttFile.Subtitles) == 0 && !blockContainsArrow(b):
+			vttFile.Styles = append(vttFile.Styles, Style{CSS: strings.Join(b.lines[1:], "\n")})
+
+		case isBlockKeyword(first, "REGION") && len(vttFile.Subtitles) == 0 && !blockContainsArrow(b):
+			vttFile.Regions = append(vttFile.Regions, parseRegion(b.lines[1:]))
+
+		default:
+			if subtitle, ok := parseCue(b); ok {
+				vttFile.Subtitles = append(vttFile.Subtitles, subtitle)
 			}
-			textLines = []string{}
-			continue
 		}
+	}
 
-		// 現在の字幕にテキスト行を追加
-		if currentSubtitle != nil {
-			textLines = append(textLines, line)
+	return vttFile
+}
+
+// parseCue はキューブロックを字幕として解析します
+// タイミング行が不正なブロックとテキストが空のキューは仕様に従って破棄します
+func parseCue(b block) (Subtitle, bool) {
+	// タイミング行の前に行がある場合はキュー識別子として扱う
+	lines := b.lines
+	line := b.line
+	id := ""
+	if !strings.Contains(lines[0], "-->") {
+		if len(lines) < 2 {
+			return Subtitle{}, false
 		}
+		id = strings.TrimSpace(lines[0])
+		lines = lines[1:]
+		line++
 	}
 
-	// 最後の字幕を追加
-	if currentSubtitle != nil && len(textLines) > 0 {
-		currentSubtitle.Text = strings.Join(textLines, "\n")
-		subtitles = append(subtitles, *currentSubtitle)
+	matches := cueTimingRegex.FindStringSubmatch(lines[0])
+	if matches == nil {
+		return Subtitle{}, false
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	startTime, err := parseTimestamp(matches[1])
+	if err != nil {
+		return Subtitle{}, false
+	}
+
+	endTime, err := parseTimestamp(matches[6])
+	if err != nil {
+		return Subtitle{}, false
 	}
 
-	return subtitles, nil
+	if len(lines) < 2 {
+		return Subtitle{}, false
+	}
+
+	return Subtitle{
+		ID:        id,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Text:      strings.Join(lines[1:], "\n"),
+		Settings:  parseCueSettings(matches[11]),
+		Line:      line,
+	}, true
 }
 
-// parseTimestamp はタイムスタンプ文字列（HH:MM:SS.mmm）をtime.Durationに変換します
+// isBlockKeyword は行がNOTE/STYLE/REGIONなどのブロックキーワードで始まるかどうかを判定します
+func isBlockKeyword(line, keyword string) bool {
+	if !strings.HasPrefix(line, keyword) {
+		return false
+	}
+	rest := line[len(keyword):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// isMetadataBlock は行がNOTE/STYLE/REGIONブロックの開始行かどうかを判定します
+func isMetadataBlock(line string) bool {
+	return isBlockKeyword(line, "NOTE") || isBlockKeyword(line, "STYLE") || isBlockKeyword(line, "REGION")
+}
+
+// blockContainsArrow はブロック内に"-->"を含む行があるかどうかを判定します
+func blockContainsArrow(b block) bool {
+	for _, line := range b.lines {
+		if strings.Contains(line, "-->") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimestamp はタイムスタンプ文字列（[HH...:]MM:SS.mmm）をtime.Durationに変換します
 func parseTimestamp(timestamp string) (time.Duration, error) {
-	parts := strings.Split(timestamp, ":")
-	if len(parts) != 3 {
+	matches := timestampRegex.FindStringSubmatch(timestamp)
+	if matches == nil {
 		return 0, ErrInvalidTimestamp
 	}
 
-	hours, err := strconv.Atoi(parts[0])
-	if err != nil {
-		return 0, err
+	hours := 0
+	if matches[1] != "" {
+		var err error
+		hours, err = strconv.Atoi(matches[1])
+		if err != nil {
+			return 0, ErrInvalidTimestamp
+		}
 	}
 
-	minutes, err := strconv.Atoi(parts[1])
+	minutes, err := strconv.Atoi(matches[2])
 	if err != nil {
-		return 0, err
-	}
-
-	secondsParts := strings.Split(parts[2], ".")
-	if len(secondsParts) != 2 {
-		return 0, ErrInvalidSecondsFormat
+		return 0, ErrInvalidTimestamp
 	}
 
-	seconds, err := strconv.Atoi(secondsParts[0])
+	seconds, err := strconv.Atoi(matches[3])
 	if err != nil {
-		return 0, err
+		return 0, ErrInvalidSecondsFormat
 	}
 
-	milliseconds, err := strconv.Atoi(secondsParts[1])
+	milliseconds, err := strconv.Atoi(matches[4])
 	if err != nil {
-		return 0, err
+		return 0, ErrInvalidSecondsFormat
 	}
 
 	duration := time.Duration(hours)*time.Hour +
@@ -0,0 +1,268 @@
+package vtt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTimestamp(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "00:01.000", want: time.Second},
+		{input: "59:59.999", want: 59*time.Minute + 59*time.Second + 999*time.Millisecond},
+		{input: "01:02:03.004", want: time.Hour + 2*time.Minute + 3*time.Second + 4*time.Millisecond},
+		{input: "100:00:00.000", want: 100 * time.Hour},
+		{input: "1:00:00.000", wantErr: true},
+		{input: "00:60.000", wantErr: true},
+		{input: "60:00.000", wantErr: true},
+		{input: "00:01.00", wantErr: true},
+		{input: "00:01,000", wantErr: true},
+		{input: "00:01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parseTimestamp(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimestamp) {
+					t.Errorf("parseTimestamp(%q) error = %v, want ErrInvalidTimestamp", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimestamp(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVTTCues(t *testing.T) {
+	type cue struct {
+		id       string
+		start    time.Duration
+		end      time.Duration
+		text     string
+		settings string
+		line     int
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []cue
+	}{
+		{
+			name:  "minimal cue",
+			input: "WEBVTT\n\n00:01.000 --> 00:02.000\nHello\n",
+			want:  []cue{{"", time.Second, 2 * time.Second, "Hello", "", 3}},
+		},
+		{
+			name:  "identifier and hours",
+			input: "WEBVTT\n\nintro-1\n01:00:01.000 --> 01:00:02.500\nHello\nworld\n",
+			want:  []cue{{"intro-1", time.Hour + time.Second, time.Hour + 2500*time.Millisecond, "Hello\nworld", "", 4}},
+		},
+		{
+			name:  "tabs around arrow and settings",
+			input: "WEBVTT\n\n00:01.000\t-->\t00:02.000 align:start line:0\nHello\n",
+			want:  []cue{{"", time.Second, 2 * time.Second, "Hello", "line:0 align:start", 3}},
+		},
+		{
+			name:  "crlf line endings",
+			input: "WEBVTT\r\n\r\n1\r\n00:01.000 --> 00:02.000\r\nHello\r\n",
+			want:  []cue{{"1", time.Second, 2 * time.Second, "Hello", "", 4}},
+		},
+		{
+			name:  "cue without blank line after header",
+			input: "WEBVTT\n00:01.000 --> 00:02.000\nHello\n",
+			want:  []cue{{"", time.Second, 2 * time.Second, "Hello", "", 2}},
+		},
+		{
+			name:  "timing line inside cue text starts a new cue",
+			input: "WEBVTT\n\n00:01.000 --> 00:02.000\nOne\n00:02.000 --> 00:03.000\nTwo\n",
+			want: []cue{
+				{"", time.Second, 2 * time.Second, "One", "", 3},
+				{"", 2 * time.Second, 3 * time.Second, "Two", "", 5},
+			},
+		},
+		{
+			name:  "invalid timing and empty cue are dropped",
+			input: "WEBVTT\n\n00:01.00 --> 00:02.000\nBad\n\n00:03.000 --> 00:04.000\n\n00:05.000 --> 00:06.000\nGood\n",
+			want:  []cue{{"", 5 * time.Second, 6 * time.Second, "Good", "", 8}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseVTT(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.ID != want.id || got.StartTime != want.start || got.EndTime != want.end ||
+					got.Text != want.text || got.Settings.String() != want.settings || got.Line != want.line {
+					t.Errorf("cue %d = {%q %v %v %q %q %d}, want %+v",
+						i, got.ID, got.StartTime, got.EndTime, got.Text, got.Settings.String(), got.Line, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseVTTHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "WEBVTT\n", want: ""},
+		{name: "with text", input: "WEBVTT - Translation\n", want: "- Translation"},
+		{name: "with tab", input: "WEBVTT\tKind\n", want: "Kind"},
+		{name: "byte order mark", input: "\uFEFFWEBVTT\n", want: ""},
+		{name: "missing", input: "00:01.000 --> 00:02.000\nHello\n", wantErr: true},
+		{name: "no separator", input: "WEBVTTX\n", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseVTT(strings.NewReader(tt.input))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidVTTHeader) {
+					t.Errorf("ParseVTT() error = %v, want ErrInvalidVTTHeader", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file.Header != tt.want {
+				t.Errorf("Header = %q, want %q", file.Header, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCueSettings(t *testing.T) {
+	tests := []struct {
+		input       string
+		want        CueSettings
+		wantInvalid []string
+	}{
+		{
+			input: "vertical:rl line:-1 position:10%,line-left size:80% align:end region:fred",
+			want:  CueSettings{Vertical: "rl", Line: "-1", Position: "10%,line-left", Size: "80%", Align: "end", Region: "fred"},
+		},
+		{
+			input: "line:50%,center position:50%",
+			want:  CueSettings{Line: "50%,center", Position: "50%"},
+		},
+		{
+			input:       "vertical:up line:-1% position:10 size:80 align:middle region:a-->b",
+			wantInvalid: []string{"vertical:up", "line:-1%", "position:10", "size:80", "align:middle", "region:a-->b"},
+		},
+		{
+			input:       "unknown:1 align: :start align:left",
+			want:        CueSettings{Align: "left"},
+			wantInvalid: []string{"unknown:1", "align:", ":start"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := parseCueSettings(tt.input); got != tt.want {
+				t.Errorf("parseCueSettings(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if got := invalidCueSettings(tt.input); strings.Join(got, " ") != strings.Join(tt.wantInvalid, " ") {
+				t.Errorf("invalidCueSettings(%q) = %q, want %q", tt.input, got, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func TestParseVTTBlocks(t *testing.T) {
+	input := "WEBVTT\n\n" +
+		"NOTE header comment\n\n" +
+		"STYLE\n::cue { color: red }\n\n" +
+		"REGION\nid:fred width:40% lines:3\nregionanchor:0%,100% viewportanchor:10%,90% scroll:up\n\n" +
+		"REGION\nid:bad width:40 lines:-1 scroll:down\n\n" +
+		"00:01.000 --> 00:02.000 region:fred\nHello\n\n" +
+		"NOTE\nmulti-line\ncomment\n\n" +
+		"STYLE\n::cue { color: blue }\n\n" +
+		"00:03.000 --> 00:04.000\nBye\n"
+
+	file, err := ParseVTT(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantNotes := []Note{{Text: "header comment", CueIndex: 0}, {Text: "multi-line\ncomment", CueIndex: 1}}
+	if len(file.Notes) != len(wantNotes) {
+		t.Fatalf("got %d notes %+v, want %d", len(file.Notes), file.Notes, len(wantNotes))
+	}
+	for i, want := range wantNotes {
+		if file.Notes[i] != want {
+			t.Errorf("note %d = %+v, want %+v", i, file.Notes[i], want)
+		}
+	}
+
+	// キューの後のSTYLEブロックは無視する
+	if len(file.Styles) != 1 || file.Styles[0].CSS != "::cue { color: red }" {
+		t.Errorf("Styles = %+v, want only the style before the cues", file.Styles)
+	}
+
+	wantRegions := []Region{
+		{ID: "fred", Width: "40%", Lines: 3, RegionAnchor: "0%,100%", ViewportAnchor: "10%,90%", Scroll: "up"},
+		{ID: "bad"},
+	}
+	if len(file.Regions) != len(wantRegions) {
+		t.Fatalf("got %d regions %+v, want %d", len(file.Regions), file.Regions, len(wantRegions))
+	}
+	for i, want := range wantRegions {
+		if file.Regions[i] != want {
+			t.Errorf("region %d = %+v, want %+v", i, file.Regions[i], want)
+		}
+	}
+
+	if len(file.Subtitles) != 2 || file.Subtitles[0].Settings.Region != "fred" || file.Subtitles[1].Text != "Bye" {
+		t.Errorf("Subtitles = %+v", file.Subtitles)
+	}
+}
+
+func TestParseVTTMetadataKeywords(t *testing.T) {
+	tests := []struct {
+		name      string
+		block     string
+		wantCues  int
+		wantNotes int
+	}{
+		{name: "note with tab", block: "NOTE\tcomment", wantNotes: 1},
+		{name: "keyword prefix is an identifier", block: "NOTES\n00:01.000 --> 00:02.000\nText", wantCues: 1},
+		{name: "style with timing is a cue", block: "STYLE\n00:01.000 --> 00:02.000\nText", wantCues: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseVTT(strings.NewReader("WEBVTT\n\n" + tt.block + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != tt.wantCues || len(file.Notes) != tt.wantNotes || len(file.Styles) != 0 {
+				t.Errorf("got %d cues, %d notes, %d styles, want %d cues, %d notes",
+					len(file.Subtitles), len(file.Notes), len(file.Styles), tt.wantCues, tt.wantNotes)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package vtt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// リージョン設定の値を検証するためのパターン
+var (
+	regionPercentRegex = regexp.MustCompile(`^\d+(\.\d+)?%$`)
+	regionAnchorRegex  = regexp.MustCompile(`^\d+(\.\d+)?%,\d+(\.\d+)?%$`)
+)
+
+// Region はREGIONブロックで定義された字幕の表示領域を表します
+type Region struct {
+	ID             string // キュー設定のregion:から参照される識別子
+	Width          string // 領域の幅（例: "40%"）
+	Lines          int    // 表示する行数（0は未指定）
+	RegionAnchor   string // 領域のアンカー位置（例: "0%,100%"）
+	ViewportAnchor string // ビューポート上のアンカー位置（例: "10%,90%"）
+	Scroll         string // スクロール方法（"up" または 空）
+}
+
+// String はリージョン定義をREGIONブロックの設定行形式に変換します
+func (r Region) String() string {
+	var settings []string
+	if r.ID != "" {
+		settings = append(settings, "id:"+r.ID)
+	}
+	if r.Width != "" {
+		settings = append(settings, "width:"+r.Width)
+	}
+	if r.Lines > 0 {
+		settings = append(settings, "lines:"+strconv.Itoa(r.Lines))
+	}
+	if r.RegionAnchor != "" {
+		settings = append(settings, "regionanchor:"+r.RegionAnchor)
+	}
+	if r.ViewportAnchor != "" {
+		settings = append(settings, "viewportanchor:"+r.ViewportAnchor)
+	}
+	if r.Scroll != "" {
+		settings = append(settings, "scroll:"+r.Scroll)
+	}
+	return strings.Join(settings, " ")
+}
+
+// parseRegion はREGIONブロックの設定行からリージョン定義を解析します
+// 不正な値は仕様に従って無視します
+func parseRegion(lines []string) Region {
+	var region Region
+
+	for _, line := range lines {
+		for _, setting := range strings.Fields(line) {
+			name, value, found := strings.Cut(setting, ":")
+			if !found || name == "" || value == "" {
+				continue
+			}
+
+			switch name {
+			case "id":
+				if !strings.Contains(value, "-->") {
+					region.ID = value
+				}
+			case "width":
+				if regionPercentRegex.MatchString(value) {
+					region.Width = value
+				}
+			case "lines":
+				if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+					region.Lines = n
+				}
+			case "regionanchor":
+				if regionAnchorRegex.MatchString(value) {
+					region.RegionAnchor = value
+				}
+			case "viewportanchor":
+				if regionAnchorRegex.MatchString(value) {
+					region.ViewportAnchor = value
+				}
+			case "scroll":
+				if value == "up" {
+					region.Scroll = value
+				}
+			}
+		}
+	}
+
+	return region
+}
@@ -0,0 +1,60 @@
+package vtt
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// sbvTimingRegex はSBVのタイミング行（0:00:01.000,0:00:04.000）を表します
+var sbvTimingRegex = regexp.MustCompile(`^\s*(\d+):(\d{1,2}):(\d{1,2})\.(\d{1,3})\s*,\s*(\d+):(\d{1,2}):(\d{1,2})\.(\d{1,3})\s*$`)
+
+// ParseSBV はリーダーからSBV形式のデータを解析します
+func ParseSBV(r io.Reader) (*VTTFile, error) {
+	scanner := newLineScanner(r)
+	vttFile := &VTTFile{Subtitles: []Subtitle{}}
+
+	var current *Subtitle
+	var textLines []string
+	flush := func() {
+		if current != nil && len(textLines) > 0 {
+			current.Text = strings.Join(textLines, "\n")
+			vttFile.Subtitles = append(vttFile.Subtitles, *current)
+		}
+		current = nil
+		textLines = nil
+	}
+
+	lineNumber := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+
+		// 空行は字幕エントリーの区切りとして扱う
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		if matches := sbvTimingRegex.FindStringSubmatch(line); matches != nil {
+			flush()
+			current = &Subtitle{
+				StartTime: srtDuration(matches[1:5]),
+				EndTime:   srtDuration(matches[5:9]),
+				Line:      lineNumber,
+			}
+			continue
+		}
+
+		if current != nil {
+			textLines = append(textLines, EscapeText(line))
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vttFile, nil
+}
@@ -0,0 +1,30 @@
+package vtt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSBV(t *testing.T) {
+	input := "0:00:01.000,0:00:03.500\nHello & welcome\nsecond line\n\n0:00:04.000,0:00:05.000\n\n0:00:06.000,0:00:07.25\n<b>Bye</b>\n"
+
+	file, err := ParseSBV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Subtitle{
+		{StartTime: time.Second, EndTime: 3500 * time.Millisecond, Text: "Hello &amp; welcome\nsecond line", Line: 1},
+		{StartTime: 6 * time.Second, EndTime: 7250 * time.Millisecond, Text: "&lt;b&gt;Bye&lt;/b&gt;", Line: 7},
+	}
+	if len(file.Subtitles) != len(want) {
+		t.Fatalf("got %d subtitles %+v, want %d", len(file.Subtitles), file.Subtitles, len(want))
+	}
+	for i, w := range want {
+		got := file.Subtitles[i]
+		if got.StartTime != w.StartTime || got.EndTime != w.EndTime || got.Text != w.Text || got.Line != w.Line {
+			t.Errorf("subtitle %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package vtt
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 再分割のデフォルト値
+const (
+	defaultSegmentMaxGap      = time.Second
+	defaultSegmentMaxDuration = 15 * time.Second
+	defaultSegmentMaxLength   = 200
+	// defaultSegmentMaxLengthCJK は単語を空白で区切らない言語での最大文字数です
+	defaultSegmentMaxLengthCJK = 100
+)
+
+// sentenceTerminators は文末を表す記号です
+const sentenceTerminators = ".!?…。！？．｡।؟"
+
+// closingPunctuation は文末記号の後に続くことがある閉じ括弧や引用符です
+const closingPunctuation = "\"')]}」』）】〉》’”"
+
+// clauseSeparators は長い文をさらに分割する際に使用する句の区切り記号です
+const clauseSeparators = ",;:、，；："
+
+// spacelessLanguages は単語を空白で区切らない言語の言語コード（先頭部分）です
+var spacelessLanguages = map[string]bool{
+	"ja": true,
+	"zh": true,
+	"th": true,
+	"lo": true,
+	"km": true,
+	"my": true,
+}
+
+// englishAbbreviations は文末と誤判定しないための英語の略語です（小文字、末尾のピリオドを含む）
+var englishAbbreviations = map[string]bool{
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "prof.": true, "sr.": true, "jr.": true,
+	"st.": true, "vs.": true, "etc.": true, "e.g.": true, "i.e.": true, "no.": true, "inc.": true,
+	"ltd.": true, "co.": true, "approx.": true, "u.s.": true, "a.m.": true, "p.m.": true,
+}
+
+// SegmentOptions は字幕の再分割の設定を表します
+// 0の値は言語ごとのデフォルト値を使用します
+type SegmentOptions struct {
+	LanguageCode string        // 文の区切りと結合方法の判定に使用する言語コード（例: "ja", "en-US"）
+	MaxGap       time.Duration // 結合する字幕の間の最大の間隔
+	MaxDuration  time.Duration // 結合後の最大の長さ。これを超える字幕は分割します
+	MaxLength    int           // 結合後の最大文字数。これを超える字幕は文の区切りで分割します
+}
+
+// withDefaults は未指定の値をデフォルト値で補った設定を返します
+func (o SegmentOptions) withDefaults() SegmentOptions {
+	if o.MaxGap <= 0 {
+		o.MaxGap = defaultSegmentMaxGap
+	}
+	if o.MaxDuration <= 0 {
+		o.MaxDuration = defaultSegmentMaxDuration
+	}
+	if o.MaxLength <= 0 {
+		o.MaxLength = defaultSegmentMaxLength
+		if o.spaceless() {
+			o.MaxLength = defaultSegmentMaxLengthCJK
+		}
+	}
+	return o
+}
+
+// spaceless は言語が単語を空白で区切らないかどうかを判定します
+func (o SegmentOptions) spaceless() bool {
+	return spacelessLanguages[o.language()]
+}
+
+// language は言語コードの先頭部分（小文字）を返します
+func (o SegmentOptions) language() string {
+	language, _, _ := strings.Cut(strings.ToLower(o.LanguageCode), "-")
+	return language
+}
+
+// joiner は字幕のテキストを結合する際の区切り文字を返します
+func (o SegmentOptions) joiner() string {
+	if o.spaceless() {
+		return ""
+	}
+	return " "
+}
+
+// Segment は音声合成のために字幕を文単位に再分割します
+// 文の途中で区切られた字幕は、話者とスタイルが同じで間隔が短い場合に次の字幕と結合し、
+// 長すぎる字幕は文の区切り（文の区切りがない場合は句の区切り）で分割します
+// 結果は音声合成用で、元の字幕（動画の字幕表示用）は変更しません
+func Segment(subtitles []Subtitle, options SegmentOptions) []Subtitle {
+	segments, _ := SegmentWithSources(subtitles, options)
+	return segments
+}
+
+// SegmentWithSources はSegmentと同様に字幕を再分割し、各字幕の元になった字幕の番号（0始まり）も返します
+// 結合した字幕は最初の字幕の番号、分割した字幕は分割前の字幕の番号になります
+func SegmentWithSources(subtitles []Subtitle, options SegmentOptions) ([]Subtitle, []int) {
+	options = options.withDefaults()
+
+	segments := make([]Subtitle, 0, len(subtitles))
+	sources := make([]int, 0, len(subtitles))
+	var current *Subtitle
+	currentSpeaker := ""
+	currentSource := 0
+
+	flush := func() {
+		pieces := splitSegment(*current, currentSpeaker, options)
+		segments = append(segments, pieces...)
+		for range pieces {
+			sources = append(sources, currentSource)
+		}
+	}
+
+	for i, subtitle := range subtitles {
+		if strings.TrimSpace(subtitle.PlainText()) == "" {
+			continue
+		}
+
+		speaker := subtitle.Speaker()
+		if current != nil && canMerge(*current, currentSpeaker, subtitle, speaker, options) {
+			current.EndTime = subtitle.EndTime
+			current.Text = joinCueText(current.Text, subtitle.Text, speaker, options)
+			if subtitle.ID != "" {
+				current.ID = joinCueID(current.ID, subtitle.ID)
+			}
+			continue
+		}
+
+		if current != nil {
+			flush()
+		}
+		merged := subtitle
+		current, currentSpeaker, currentSource = &merged, speaker, i
+	}
+
+	if current != nil {
+		flush()
+	}
+
+	return segments, sources
+}
+
+// canMerge は字幕を直前の字幕に結合できるかどうかを判定します
+func canMerge(previous Subtitle, previousSpeaker string, next Subtitle, nextSpeaker string, options SegmentOptions) bool {
+	if endsSentence(previous.PlainText(), options) {
+		return false
+	}
+	if previousSpeaker != nextSpeaker || previous.Style != next.Style {
+		return false
+	}
+	if next.StartTime < previous.EndTime-options.MaxGap || next.StartTime-previous.EndTime > options.MaxGap {
+		return false
+	}
+	if next.EndTime-previous.StartTime > options.MaxDuration {
+		return false
+	}
+	length := utf8.RuneCountInString(previous.PlainText()) + utf8.RuneCountInString(next.PlainText())
+	return length <= options.MaxLength
+}
+
+// joinCueText は2つの字幕のテキストを1つの文として結合します
+// 字幕内の改行は表示のための折り返しとみなして区切り文字に置き換え、
+// 後の字幕の先頭にある同じ話者の<v>タグは取り除きます
+func joinCueText(previous, next, speaker string, options SegmentOptions) string {
+	joiner := options.joiner()
+	previous = strings.ReplaceAll(strings.TrimSpace(previous), "\n", joiner)
+	next = strings.ReplaceAll(strings.TrimSpace(next), "\n", joiner)
+	if speaker != "" && strings.HasPrefix(next, "<v") {
+		if end := strings.Index(next, ">"); end >= 0 {
+			next = next[end+1:]
+		}
+	}
+	return previous + joiner + next
+}
+
+// joinCueID は結合した字幕のキュー識別子を"+"で連結します
+func joinCueID(previous, next string) string {
+	if previous == "" {
+		return next
+	}
+	return previous + "+" + next
+}
+
+// endsSentence はテキストが文末で終わっているかどうかを判定します
+func endsSentence(text string, options SegmentOptions) bool {
+	text = strings.TrimRightFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(closingPunctuation, r)
+	})
+	last, _ := utf8.DecodeLastRuneInString(text)
+	if last == utf8.RuneError || !strings.ContainsRune(sentenceTerminators, last) {
+		return false
+	}
+	return !(last == '.' && isAbbreviation(text, options))
+}
+
+// isAbbreviation はピリオドで終わるテキストの最後の単語が略語かどうかを判定します
+func isAbbreviation(text string, options SegmentOptions) bool {
+	if options.language() != "en" {
+		return false
+	}
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return false
+	}
+	word := strings.ToLower(strings.TrimLeft(words[len(words)-1], closingPunctuation+"(["))
+	return englishAbbreviations[word]
+}
+
+// splitCueID は分割した字幕のキュー識別子として、元の識別子に"#"と1始まりの番号を付けます
+// 元の字幕に識別子がない場合は空のままにします
+func splitCueID(id string, number int) string {
+	if id == "" {
+		return ""
+	}
+	return id + "#" + strconv.Itoa(number)
+}
+
+// splitSegment は長すぎる字幕を文の区切りで分割し、時間を文字数に比例して割り当てます
+// 分割した字幕のテキストはマークアップを除いたテキストになります（話者の<v>タグは保持します）
+// 分割した字幕のキュー識別子には"#"と番号を付けます（"intro" → "intro#1", "intro#2"）
+func splitSegment(subtitle Subtitle, speaker string, options SegmentOptions) []Subtitle {
+	text := strings.TrimSpace(subtitle.PlainText())
+	length := utf8.RuneCountInString(text)
+	if length <= options.MaxLength && subtitle.EndTime-subtitle.StartTime <= options.MaxDuration {
+		return []Subtitle{subtitle}
+	}
+
+	// 最大の長さに収まる字幕の数から1つあたりの文字数の上限を決める
+	maxLength := options.MaxLength
+	if duration := subtitle.EndTime - subtitle.StartTime; duration > options.MaxDuration {
+		pieces := int((duration + options.MaxDuration - 1) / options.MaxDuration)
+		maxLength = min(maxLength, max(1, (length+pieces-1)/pieces))
+	}
+
+	parts := packSentences(splitSentences(strings.ReplaceAll(text, "\n", options.joiner()), options), maxLength, options)
+	if len(parts) <= 1 {
+		return []Subtitle{subtitle}
+	}
+
+	segments := make([]Subtitle, 0, len(parts))
+	duration := subtitle.EndTime - subtitle.StartTime
+	offset := 0
+	for i, part := range parts {
+		partLength := utf8.RuneCountInString(part)
+		segment := subtitle
+		segment.ID = splitCueID(subtitle.ID, i+1)
+		segment.StartTime = subtitle.StartTime + duration*time.Duration(offset)/time.Duration(length)
+		offset += partLength
+		segment.EndTime = subtitle.StartTime + duration*time.Duration(min(offset, length))/time.Duration(length)
+		segment.Text = EscapeText(part)
+		if speaker != "" {
+			segment.Text = "<v " + EscapeText(speaker) + ">" + segment.Text
+		}
+		segments = append(segments, segment)
+	}
+	segments[len(segments)-1].EndTime = subtitle.EndTime
+
+	return segments
+}
+
+// splitSentences はテキストを文に分割します
+// 分割した文の先頭と末尾の空白は取り除かれ、文の間の文字数は失われます
+func splitSentences(text string, options SegmentOptions) []string {
+	return splitAfter(text, func(runes []rune, i int) bool {
+		// 連続する文末記号と閉じ括弧は同じ文に含める
+		if i+1 < len(runes) && (strings.ContainsRune(sentenceTerminators, runes[i+1]) || strings.ContainsRune(closingPunctuation, runes[i+1])) {
+			return false
+		}
+
+		// 閉じ括弧の前にある文末記号を探す
+		terminator := i
+		for terminator > 0 && strings.ContainsRune(closingPunctuation, runes[terminator]) {
+			terminator--
+		}
+		if !strings.ContainsRune(sentenceTerminators, runes[terminator]) {
+			return false
+		}
+
+		// 全角の文末記号は空白が続かなくても文末とする
+		if runes[terminator] >= utf8.RuneSelf && runes[terminator] != '…' {
+			return true
+		}
+		if i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			return false
+		}
+		return !(runes[terminator] == '.' && isAbbreviation(string(runes[:terminator+1]), options))
+	})
+}
+
+// splitClauses は文を句の区切りで分割します
+func splitClauses(text string) []string {
+	return splitAfter(text, func(runes []rune, i int) bool {
+		if !strings.ContainsRune(clauseSeparators, runes[i]) {
+			return false
+		}
+		return runes[i] >= utf8.RuneSelf || i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+	})
+}
+
+// splitAfter はisBoundaryが真となる文字の直後でテキストを分割します
+func splitAfter(text string, isBoundary func(runes []rune, i int) bool) []string {
+	runes := []rune(text)
+	var parts []string
+	start := 0
+	for i := range runes {
+		if isBoundary(runes, i) {
+			if part := strings.TrimSpace(string(runes[start : i+1])); part != "" {
+				parts = append(parts, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := strings.TrimSpace(string(runes[start:])); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// packSentences は文を最大文字数に収まるようにまとめます
+// 1文で最大文字数を超える場合は句の区切りで、それでも超える場合は文字数で分割します
+func packSentences(sentences []string, maxLength int, options SegmentOptions) []string {
+	var pieces []string
+	for _, sentence := range sentences {
+		if utf8.RuneCountInString(sentence) <= maxLength {
+			pieces = append(pieces, sentence)
+			continue
+		}
+		for _, clause := range splitClauses(sentence) {
+			pieces = append(pieces, splitByLength(clause, maxLength, options)...)
+		}
+	}
+
+	var parts []string
+	current := ""
+	for _, piece := range pieces {
+		if current == "" {
+			current = piece
+			continue
+		}
+		joined := current + options.joiner() + piece
+		if utf8.RuneCountInString(joined) > maxLength {
+			parts = append(parts, current)
+			current = piece
+			continue
+		}
+		current = joined
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
+
+// splitByLength はテキストを最大文字数ごとに分割します
+// 空白で区切る言語では単語の途中で分割しないようにします
+func splitByLength(text string, maxLength int, options SegmentOptions) []string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return []string{text}
+	}
+
+	if options.spaceless() {
+		runes := []rune(text)
+		var parts []string
+		for start := 0; start < len(runes); start += maxLength {
+			parts = append(parts, string(runes[start:min(start+maxLength, len(runes))]))
+		}
+		return parts
+	}
+
+	var parts []string
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) > maxLength {
+			parts = append(parts, current)
+			current = ""
+		}
+		if current == "" {
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	if current != "" {
+		parts = append(parts, current)
+	}
+	return parts
+}
@@ -0,0 +1,174 @@
+package vtt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSegment(t *testing.T) {
+	type cue struct {
+		id    string
+		start time.Duration
+		end   time.Duration
+		text  string
+	}
+
+	tests := []struct {
+		name    string
+		options SegmentOptions
+		input   []cue
+		want    []cue
+	}{
+		{
+			name:    "merge unfinished sentence",
+			options: SegmentOptions{LanguageCode: "en"},
+			input: []cue{
+				{"1", 0, 2 * time.Second, "This is a sentence"},
+				{"2", 2 * time.Second, 4 * time.Second, "split in two."},
+				{"3", 4 * time.Second, 5 * time.Second, "Next."},
+			},
+			want: []cue{
+				{"1+2", 0, 4 * time.Second, "This is a sentence split in two."},
+				{"3", 4 * time.Second, 5 * time.Second, "Next."},
+			},
+		},
+		{
+			name:    "japanese joins without space",
+			options: SegmentOptions{LanguageCode: "ja"},
+			input: []cue{
+				{"", 0, time.Second, "今日は"},
+				{"", time.Second, 2 * time.Second, "晴れです。"},
+			},
+			want: []cue{
+				{"", 0, 2 * time.Second, "今日は晴れです。"},
+			},
+		},
+		{
+			name:    "abbreviation does not end sentence",
+			options: SegmentOptions{LanguageCode: "en"},
+			input: []cue{
+				{"", 0, time.Second, "Ask Dr."},
+				{"", time.Second, 2 * time.Second, "Smith."},
+			},
+			want: []cue{
+				{"", 0, 2 * time.Second, "Ask Dr. Smith."},
+			},
+		},
+		{
+			name:    "gap prevents merge",
+			options: SegmentOptions{LanguageCode: "en"},
+			input: []cue{
+				{"", 0, time.Second, "Wait"},
+				{"", 5 * time.Second, 6 * time.Second, "for it."},
+			},
+			want: []cue{
+				{"", 0, time.Second, "Wait"},
+				{"", 5 * time.Second, 6 * time.Second, "for it."},
+			},
+		},
+		{
+			name:    "different speakers are not merged",
+			options: SegmentOptions{LanguageCode: "en"},
+			input: []cue{
+				{"", 0, time.Second, "<v Alice>Are you"},
+				{"", time.Second, 2 * time.Second, "<v Bob>coming?"},
+			},
+			want: []cue{
+				{"", 0, time.Second, "<v Alice>Are you"},
+				{"", time.Second, 2 * time.Second, "<v Bob>coming?"},
+			},
+		},
+		{
+			name:    "split long cue with distinct ids",
+			options: SegmentOptions{LanguageCode: "en", MaxLength: 10},
+			input: []cue{
+				{"intro", 0, 1700 * time.Millisecond, "One two. Six six."},
+			},
+			want: []cue{
+				{"intro#1", 0, 800 * time.Millisecond, "One two."},
+				{"intro#2", 800 * time.Millisecond, 1700 * time.Millisecond, "Six six."},
+			},
+		},
+		{
+			name:    "split keeps escaped speaker",
+			options: SegmentOptions{LanguageCode: "en", MaxLength: 10},
+			input: []cue{
+				{"", 0, 1700 * time.Millisecond, "<v Tom &amp; Jerry>One two. Six six."},
+			},
+			want: []cue{
+				{"", 0, 800 * time.Millisecond, "<v Tom &amp; Jerry>One two."},
+				{"", 800 * time.Millisecond, 1700 * time.Millisecond, "<v Tom &amp; Jerry>Six six."},
+			},
+		},
+		{
+			name:    "empty cue is dropped",
+			options: SegmentOptions{LanguageCode: "en"},
+			input: []cue{
+				{"", 0, time.Second, "<i></i>"},
+				{"", time.Second, 2 * time.Second, "Hello."},
+			},
+			want: []cue{
+				{"", time.Second, 2 * time.Second, "Hello."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subtitles := make([]Subtitle, len(tt.input))
+			for i, c := range tt.input {
+				subtitles[i] = Subtitle{ID: c.id, StartTime: c.start, EndTime: c.end, Text: c.text}
+			}
+
+			segments := Segment(subtitles, tt.options)
+			if len(segments) != len(tt.want) {
+				t.Fatalf("got %d segments %+v, want %d", len(segments), segments, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := segments[i]
+				if got.ID != want.id || got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text {
+					t.Errorf("segment %d = {%q %v %v %q}, want {%q %v %v %q}",
+						i, got.ID, got.StartTime, got.EndTime, got.Text, want.id, want.start, want.end, want.text)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentSplitsLongDuration(t *testing.T) {
+	text := strings.Repeat("word ", 20) + "end."
+	segments := Segment([]Subtitle{{StartTime: 0, EndTime: 40 * time.Second, Text: text}}, SegmentOptions{LanguageCode: "en"})
+	if len(segments) < 3 {
+		t.Fatalf("got %d segments, want at least 3", len(segments))
+	}
+	for i, segment := range segments {
+		if segment.EndTime-segment.StartTime > defaultSegmentMaxDuration {
+			t.Errorf("segment %d lasts %v", i, segment.EndTime-segment.StartTime)
+		}
+	}
+	if segments[len(segments)-1].EndTime != 40*time.Second {
+		t.Errorf("last segment ends at %v", segments[len(segments)-1].EndTime)
+	}
+}
+
+func TestSegmentWithSources(t *testing.T) {
+	subtitles := []Subtitle{
+		{ID: "a", StartTime: 0, EndTime: time.Second, Text: "<i></i>"},
+		{ID: "b", StartTime: time.Second, EndTime: 2 * time.Second, Text: "This is"},
+		{ID: "c", StartTime: 2 * time.Second, EndTime: 3 * time.Second, Text: "one."},
+		{ID: "d", StartTime: 3 * time.Second, EndTime: 4700 * time.Millisecond, Text: "One two. Six six."},
+	}
+
+	segments, sources := SegmentWithSources(subtitles, SegmentOptions{LanguageCode: "en", MaxLength: 12})
+	wantIDs := []string{"b+c", "d#1", "d#2"}
+	wantSources := []int{1, 3, 3}
+	if len(segments) != len(wantIDs) || len(sources) != len(wantSources) {
+		t.Fatalf("got %d segments and %d sources, want %d", len(segments), len(sources), len(wantIDs))
+	}
+	for i := range wantIDs {
+		if segments[i].ID != wantIDs[i] || sources[i] != wantSources[i] {
+			t.Errorf("segment %d = %q from %d, want %q from %d", i, segments[i].ID, sources[i], wantIDs[i], wantSources[i])
+		}
+	}
+}
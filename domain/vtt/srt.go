@@ -0,0 +1,149 @@
+package vtt
+
+import (
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SRT（SubRip）形式を解析するためのパターン
+var (
+	// srtTimingRegex はSRTのタイミング行（00:00:01,000 --> 00:00:04,000）を表します
+	// ミリ秒の区切りにピリオドを使うファイルや、末尾に座標指定（X1:...）があるファイルも受け付けます
+	srtTimingRegex = regexp.MustCompile(`^\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})\s*-->\s*(\d+):(\d{1,2}):(\d{1,2})[,.](\d{1,3})`)
+	// srtTagRegex はSRTのテキスト内のHTML風タグとASS風の上書きタグを表します
+	srtTagRegex = regexp.MustCompile(`(?i)</?(i|b|u|font)(\s[^>]*)?>|\{\\[^}]*\}`)
+)
+
+// ParseSRT はリーダーからSRT形式のデータを解析します
+// 番号行はキュー識別子として、<i>, <b>, <u>タグはキューテキストのタグとして保持します
+func ParseSRT(r io.Reader) (*VTTFile, error) {
+	scanner := newLineScanner(r)
+	vttFile := &VTTFile{Subtitles: []Subtitle{}}
+
+	var lines []string
+	firstLine := 0 // linesの最初の行の行番号
+	lineNumber := 0
+	flush := func() {
+		if subtitle, ok := parseSRTBlock(lines, firstLine); ok {
+			vttFile.Subtitles = append(vttFile.Subtitles, subtitle)
+		}
+		lines = nil
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+
+		// 空行は字幕エントリーの区切りとして扱う
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+
+		// 空行なしで次の字幕が始まる場合（番号行の次がタイミング行）にも区切る
+		if len(lines) > 2 && srtTimingRegex.MatchString(line) && isSRTIndex(lines[len(lines)-1]) {
+			index := lines[len(lines)-1]
+			lines = lines[:len(lines)-1]
+			flush()
+			lines = append(lines, index)
+			firstLine = lineNumber - 1
+		}
+
+		if len(lines) == 0 {
+			firstLine = lineNumber
+		}
+		lines = append(lines, line)
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vttFile, nil
+}
+
+// parseSRTBlock は1つの字幕エントリーの行を字幕に変換します
+// firstLine はエントリーの最初の行の行番号です
+func parseSRTBlock(lines []string, firstLine int) (Subtitle, bool) {
+	if len(lines) == 0 {
+		return Subtitle{}, false
+	}
+
+	id := ""
+	if isSRTIndex(lines[0]) {
+		id = strings.TrimSpace(lines[0])
+		lines = lines[1:]
+		firstLine++
+	}
+	if len(lines) < 2 {
+		return Subtitle{}, false
+	}
+
+	matches := srtTimingRegex.FindStringSubmatch(lines[0])
+	if matches == nil {
+		return Subtitle{}, false
+	}
+
+	textLines := make([]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		textLines = append(textLines, convertSRTText(line))
+	}
+
+	return Subtitle{
+		ID:        id,
+		StartTime: srtDuration(matches[1:5]),
+		EndTime:   srtDuration(matches[5:9]),
+		Text:      strings.Join(textLines, "\n"),
+		Line:      firstLine,
+	}, true
+}
+
+// isSRTIndex は行がSRTの番号行かどうかを判定します
+func isSRTIndex(line string) bool {
+	_, err := strconv.Atoi(strings.TrimSpace(line))
+	return err == nil
+}
+
+// srtDuration は時・分・秒・ミリ秒の文字列をtime.Durationに変換します
+func srtDuration(parts []string) time.Duration {
+	hours, _ := strconv.Atoi(parts[0])
+	minutes, _ := strconv.Atoi(parts[1])
+	seconds, _ := strconv.Atoi(parts[2])
+	// "5"のような桁数の少ないミリ秒は小数として扱う（",5"は500ミリ秒）
+	milliseconds, _ := strconv.Atoi((parts[3] + "00")[:3])
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(milliseconds)*time.Millisecond
+}
+
+// convertSRTText はSRTのテキスト行をWebVTTのキューテキストに変換します
+// <i>, <b>, <u>タグは保持し、<font>タグとASS風の上書きタグは削除し、その他の文字はエスケープします
+func convertSRTText(line string) string {
+	var builder strings.Builder
+	last := 0
+	for _, loc := range srtTagRegex.FindAllStringSubmatchIndex(line, -1) {
+		builder.WriteString(EscapeText(line[last:loc[0]]))
+		last = loc[1]
+
+		if loc[2] < 0 {
+			continue
+		}
+		name := strings.ToLower(line[loc[2]:loc[3]])
+		if name == "font" {
+			continue
+		}
+		if strings.HasPrefix(line[loc[0]:loc[1]], "</") {
+			builder.WriteString("</" + name + ">")
+		} else {
+			builder.WriteString("<" + name + ">")
+		}
+	}
+	builder.WriteString(EscapeText(line[last:]))
+	return builder.String()
+}
@@ -0,0 +1,83 @@
+package vtt
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSRT(t *testing.T) {
+	type cue struct {
+		id    string
+		start time.Duration
+		end   time.Duration
+		text  string
+		line  int
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []cue
+	}{
+		{
+			name:  "basic entries",
+			input: "1\n00:00:01,000 --> 00:00:02,500\nHello\nworld\n\n2\n00:00:03,000 --> 00:00:04,000\nBye\n",
+			want: []cue{
+				{"1", time.Second, 2500 * time.Millisecond, "Hello\nworld", 2},
+				{"2", 3 * time.Second, 4 * time.Second, "Bye", 7},
+			},
+		},
+		{
+			name:  "period separator, short milliseconds and coordinates",
+			input: "1\n0:00:01.5 --> 0:00:02.25 X1:100 X2:200\nHello\n",
+			want:  []cue{{"1", 1500 * time.Millisecond, 2250 * time.Millisecond, "Hello", 2}},
+		},
+		{
+			name:  "missing blank line between entries",
+			input: "1\n00:00:01,000 --> 00:00:02,000\nOne\n2\n00:00:02,000 --> 00:00:03,000\nTwo\n",
+			want: []cue{
+				{"1", time.Second, 2 * time.Second, "One", 2},
+				{"2", 2 * time.Second, 3 * time.Second, "Two", 5},
+			},
+		},
+		{
+			name:  "missing index and crlf",
+			input: "00:00:01,000 --> 00:00:02,000\r\nHello\r\n\r\n\r\n",
+			want:  []cue{{"", time.Second, 2 * time.Second, "Hello", 1}},
+		},
+		{
+			name:  "number as text is kept",
+			input: "1\n00:00:01,000 --> 00:00:02,000\n42\n",
+			want:  []cue{{"1", time.Second, 2 * time.Second, "42", 2}},
+		},
+		{
+			name:  "entry without text or timing is dropped",
+			input: "1\n00:00:01,000 --> 00:00:02,000\n\n2\nnot a timing\nText\n\n3\n00:00:05,000 --> 00:00:06,000\nKept\n",
+			want:  []cue{{"3", 5 * time.Second, 6 * time.Second, "Kept", 9}},
+		},
+		{
+			name:  "tags are converted",
+			input: "1\n00:00:01,000 --> 00:00:02,000\n<I>Tom</I> & <font color=\"red\">Jerry</font> {\\an8}<b>1 < 2</b>\n",
+			want:  []cue{{"1", time.Second, 2 * time.Second, "<i>Tom</i> &amp; Jerry <b>1 &lt; 2</b>", 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseSRT(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.ID != want.id || got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text || got.Line != want.line {
+					t.Errorf("cue %d = {%q %v %v %q %d}, want %+v", i, got.ID, got.StartTime, got.EndTime, got.Text, got.Line, want)
+				}
+			}
+		})
+	}
+}
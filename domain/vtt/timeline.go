@@ -0,0 +1,193 @@
+package vtt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTimeline はタイムラインの変換に不正な値が指定された場合のエラーです
+var ErrInvalidTimeline = errors.New("invalid timeline transform")
+
+// ntscFrameRates はNTSC系のフレームレートの表記と正確な値（1000/1001倍）の対応です
+var ntscFrameRates = map[string]float64{
+	"23.976": 24000.0 / 1001.0,
+	"23.98":  24000.0 / 1001.0,
+	"29.97":  30000.0 / 1001.0,
+	"47.952": 48000.0 / 1001.0,
+	"59.94":  60000.0 / 1001.0,
+	"119.88": 120000.0 / 1001.0,
+}
+
+// TimelineOptions は字幕全体のタイミングに適用する変換を表します
+// 変換はフレームレートの変換、拡大縮小、オフセット、切り出しの順に適用されます
+type TimelineOptions struct {
+	SourceFrameRate float64       // 変換元のフレームレート（0の場合はフレームレートを変換しない）
+	TargetFrameRate float64       // 変換先のフレームレート
+	Scale           float64       // タイミングに掛ける倍率（0の場合は1として扱う）
+	Offset          time.Duration // すべてのタイミングに加える時間（負の値で前にずらす）
+	TrimStart       time.Duration // 切り出す区間の開始時間（この時間が0秒になる）
+	TrimEnd         time.Duration // 切り出す区間の終了時間（0の場合は最後まで）
+}
+
+// IsZero は変換が何も指定されていないかどうかを判定します
+func (o TimelineOptions) IsZero() bool {
+	return o == TimelineOptions{}
+}
+
+// Apply は字幕ファイルに変換を順に適用します
+func (o TimelineOptions) Apply(vttFile *VTTFile) error {
+	if o.SourceFrameRate != 0 || o.TargetFrameRate != 0 {
+		if err := vttFile.ConvertFrameRate(o.SourceFrameRate, o.TargetFrameRate); err != nil {
+			return err
+		}
+	}
+	if o.Scale != 0 && o.Scale != 1 {
+		if err := vttFile.Scale(o.Scale); err != nil {
+			return err
+		}
+	}
+	if o.Offset != 0 {
+		vttFile.Offset(o.Offset)
+	}
+	if o.TrimStart != 0 || o.TrimEnd != 0 {
+		if err := vttFile.Trim(o.TrimStart, o.TrimEnd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Offset はすべての字幕（キュー内のタイムスタンプを含む）のタイミングをずらします
+// 0秒より前に終わる字幕は削除し、0秒より前に始まる字幕は0秒から始まるようにします
+func (f *VTTFile) Offset(offset time.Duration) {
+	f.mapTimes(func(t time.Duration) time.Duration {
+		return t + offset
+	})
+	f.clip(0, 0)
+}
+
+// Scale はすべての字幕（キュー内のタイムスタンプを含む）のタイミングに倍率を掛けます
+func (f *VTTFile) Scale(factor float64) error {
+	if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+		return fmt.Errorf("%w: 倍率は正の値で指定してください: %v", ErrInvalidTimeline, factor)
+	}
+	f.mapTimes(func(t time.Duration) time.Duration {
+		return time.Duration(math.Round(float64(t) * factor))
+	})
+	return nil
+}
+
+// ConvertFrameRate は変換元のフレームレートで作成された字幕を変換先のフレームレートに合わせます
+// 例えば23.976fpsから25fpsへの変換（PALスピードアップ）では、タイミングを23.976/25倍にします
+func (f *VTTFile) ConvertFrameRate(source, target float64) error {
+	if source <= 0 || target <= 0 {
+		return fmt.Errorf("%w: フレームレートは正の値で指定してください: %v -> %v", ErrInvalidTimeline, source, target)
+	}
+	return f.Scale(source / target)
+}
+
+// Trim は指定された区間に含まれる字幕だけを残し、開始時間が0秒になるようにずらします
+// 区間の境界にまたがる字幕は区間内に収まるように短くします。endが0の場合は最後までを残します
+func (f *VTTFile) Trim(start, end time.Duration) error {
+	if start < 0 || (end != 0 && end <= start) {
+		return fmt.Errorf("%w: 切り出す区間が不正です: %s -> %s", ErrInvalidTimeline, FormatTimestamp(start), FormatTimestamp(end))
+	}
+	f.clip(start, end)
+	f.mapTimes(func(t time.Duration) time.Duration {
+		return t - start
+	})
+	return nil
+}
+
+// clip は区間[start, end)と重ならない字幕を削除し、境界にまたがる字幕を区間内に収めます
+// endが0の場合は終了時間を制限しません。削除した字幕に合わせてコメントの位置も調整します
+func (f *VTTFile) clip(start, end time.Duration) {
+	subtitles := f.Subtitles[:0]
+	// kept[i] は元のi番目までの字幕のうち残った字幕の数
+	kept := make([]int, len(f.Subtitles)+1)
+
+	for i, subtitle := range f.Subtitles {
+		kept[i+1] = kept[i]
+		if subtitle.EndTime <= start || (end != 0 && subtitle.StartTime >= end) {
+			continue
+		}
+
+		subtitle.StartTime = max(subtitle.StartTime, start)
+		if end != 0 {
+			subtitle.EndTime = min(subtitle.EndTime, end)
+		}
+		subtitle.Text = mapCueTimestamps(subtitle.Text, func(t time.Duration) time.Duration {
+			return min(max(t, subtitle.StartTime), subtitle.EndTime)
+		})
+
+		subtitles = append(subtitles, subtitle)
+		kept[i+1]++
+	}
+	f.Subtitles = subtitles
+
+	for i := range f.Notes {
+		f.Notes[i].CueIndex = kept[min(max(f.Notes[i].CueIndex, 0), len(kept)-1)]
+	}
+}
+
+// mapTimes はすべての字幕の開始時間、終了時間、キュー内のタイムスタンプを変換します
+func (f *VTTFile) mapTimes(fn func(time.Duration) time.Duration) {
+	for i := range f.Subtitles {
+		subtitle := &f.Subtitles[i]
+		subtitle.StartTime = fn(subtitle.StartTime)
+		subtitle.EndTime = fn(subtitle.EndTime)
+		subtitle.Text = mapCueTimestamps(subtitle.Text, fn)
+	}
+}
+
+// mapCueTimestamps はキューテキスト内のタイムスタンプタグ（<00:00:01.500>）を変換します
+// 解析できないタイムスタンプはそのまま残します
+func mapCueTimestamps(text string, fn func(time.Duration) time.Duration) string {
+	if !strings.Contains(text, "<") {
+		return text
+	}
+	return cueTimestampTagRegex.ReplaceAllStringFunc(text, func(tag string) string {
+		timestamp, err := parseTimestamp(tag[1 : len(tag)-1])
+		if err != nil {
+			return tag
+		}
+		return "<" + FormatTimestamp(max(fn(timestamp), 0)) + ">"
+	})
+}
+
+// ParseFrameRate はフレームレートの表記（"25", "23.976", "24000/1001"など）を解析します
+// "23.976"や"29.97"などのNTSC系の表記は正確な値（24000/1001など）として扱います
+func ParseFrameRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if rate, ok := ntscFrameRates[value]; ok {
+		return rate, nil
+	}
+
+	var rate float64
+	if numerator, denominator, found := strings.Cut(value, "/"); found {
+		n, err := strconv.ParseFloat(numerator, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: フレームレートが不正です: %s", ErrInvalidTimeline, value)
+		}
+		d, err := strconv.ParseFloat(denominator, 64)
+		if err != nil || d == 0 {
+			return 0, fmt.Errorf("%w: フレームレートが不正です: %s", ErrInvalidTimeline, value)
+		}
+		rate = n / d
+	} else {
+		var err error
+		rate, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: フレームレートが不正です: %s", ErrInvalidTimeline, value)
+		}
+	}
+
+	if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+		return 0, fmt.Errorf("%w: フレームレートは正の値で指定してください: %s", ErrInvalidTimeline, value)
+	}
+	return rate, nil
+}
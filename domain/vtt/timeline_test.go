@@ -0,0 +1,180 @@
+package vtt
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTimelineApply(t *testing.T) {
+	type cue struct {
+		start time.Duration
+		end   time.Duration
+		text  string
+	}
+
+	input := []cue{
+		{time.Second, 2 * time.Second, "One"},
+		{3 * time.Second, 5 * time.Second, "Two <00:00:04.000>words"},
+		{6 * time.Second, 8 * time.Second, "Three"},
+	}
+
+	tests := []struct {
+		name      string
+		options   TimelineOptions
+		want      []cue
+		wantNotes []int
+	}{
+		{
+			name:      "no transform",
+			options:   TimelineOptions{},
+			want:      input,
+			wantNotes: []int{1, 3},
+		},
+		{
+			name:    "positive offset",
+			options: TimelineOptions{Offset: 500 * time.Millisecond},
+			want: []cue{
+				{1500 * time.Millisecond, 2500 * time.Millisecond, "One"},
+				{3500 * time.Millisecond, 5500 * time.Millisecond, "Two <00:00:04.500>words"},
+				{6500 * time.Millisecond, 8500 * time.Millisecond, "Three"},
+			},
+			wantNotes: []int{1, 3},
+		},
+		{
+			name:    "negative offset drops and clips cues",
+			options: TimelineOptions{Offset: -4500 * time.Millisecond},
+			want: []cue{
+				{0, 500 * time.Millisecond, "Two <00:00:00.000>words"},
+				{1500 * time.Millisecond, 3500 * time.Millisecond, "Three"},
+			},
+			wantNotes: []int{0, 2},
+		},
+		{
+			name:    "scale",
+			options: TimelineOptions{Scale: 2},
+			want: []cue{
+				{2 * time.Second, 4 * time.Second, "One"},
+				{6 * time.Second, 10 * time.Second, "Two <00:00:08.000>words"},
+				{12 * time.Second, 16 * time.Second, "Three"},
+			},
+			wantNotes: []int{1, 3},
+		},
+		{
+			name:    "frame rate conversion",
+			options: TimelineOptions{SourceFrameRate: 25, TargetFrameRate: 50},
+			want: []cue{
+				{500 * time.Millisecond, time.Second, "One"},
+				{1500 * time.Millisecond, 2500 * time.Millisecond, "Two <00:00:02.000>words"},
+				{3 * time.Second, 4 * time.Second, "Three"},
+			},
+			wantNotes: []int{1, 3},
+		},
+		{
+			name:    "trim",
+			options: TimelineOptions{TrimStart: 4 * time.Second, TrimEnd: 7 * time.Second},
+			want: []cue{
+				{0, time.Second, "Two <00:00:00.000>words"},
+				{2 * time.Second, 3 * time.Second, "Three"},
+			},
+			wantNotes: []int{0, 2},
+		},
+		{
+			name:    "offset is applied after scale",
+			options: TimelineOptions{Scale: 2, Offset: -2 * time.Second},
+			want: []cue{
+				{0, 2 * time.Second, "One"},
+				{4 * time.Second, 8 * time.Second, "Two <00:00:06.000>words"},
+				{10 * time.Second, 14 * time.Second, "Three"},
+			},
+			wantNotes: []int{1, 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &VTTFile{Notes: []Note{{Text: "after one", CueIndex: 1}, {Text: "end", CueIndex: 3}}}
+			for _, c := range input {
+				file.Subtitles = append(file.Subtitles, Subtitle{StartTime: c.start, EndTime: c.end, Text: c.text})
+			}
+
+			if err := tt.options.Apply(file); err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d cues %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text {
+					t.Errorf("cue %d = {%v %v %q}, want %+v", i, got.StartTime, got.EndTime, got.Text, want)
+				}
+			}
+			for i, want := range tt.wantNotes {
+				if got := file.Notes[i].CueIndex; got != want {
+					t.Errorf("note %d CueIndex = %d, want %d", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		options TimelineOptions
+	}{
+		{"negative scale", TimelineOptions{Scale: -1}},
+		{"missing target frame rate", TimelineOptions{SourceFrameRate: 25}},
+		{"negative trim start", TimelineOptions{TrimStart: -time.Second}},
+		{"trim end before start", TimelineOptions{TrimStart: 2 * time.Second, TrimEnd: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := &VTTFile{Subtitles: []Subtitle{{StartTime: time.Second, EndTime: 2 * time.Second, Text: "One"}}}
+			if err := tt.options.Apply(file); !errors.Is(err, ErrInvalidTimeline) {
+				t.Errorf("Apply() error = %v, want ErrInvalidTimeline", err)
+			}
+		})
+	}
+}
+
+func TestParseFrameRate(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{input: "25", want: 25},
+		{input: " 30 ", want: 30},
+		{input: "23.976", want: 24000.0 / 1001.0},
+		{input: "29.97", want: 30000.0 / 1001.0},
+		{input: "24000/1001", want: 24000.0 / 1001.0},
+		{input: "23.5", want: 23.5},
+		{input: "0", wantErr: true},
+		{input: "-25", wantErr: true},
+		{input: "25/0", wantErr: true},
+		{input: "fast", wantErr: true},
+		{input: "Inf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFrameRate(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimeline) {
+					t.Errorf("ParseFrameRate(%q) error = %v, want ErrInvalidTimeline", tt.input, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("ParseFrameRate(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
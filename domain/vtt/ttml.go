@@ -0,0 +1,498 @@
+package vtt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// TTMLの解析のエラー定義
+var (
+	// ErrInvalidTTML はTTMLドキュメントとして解析できない場合のエラーです
+	ErrInvalidTTML = errors.New("invalid TTML document: missing tt element")
+	// ErrUnsupportedTTML はTTMLドキュメントが対応していない機能を使用している場合のエラーです
+	ErrUnsupportedTTML = errors.New("unsupported TTML feature")
+)
+
+// TTMLの時間表現を解析するためのパターン
+var (
+	// ttmlClockTimeRegex はクロック時間（hh:mm:ss.fraction または hh:mm:ss:frames.subframes）を表します
+	ttmlClockTimeRegex = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})(?:(\.\d+)|:(\d{2,})(?:\.(\d+))?)?$`)
+	// ttmlOffsetTimeRegex はオフセット時間（1.5s, 30f, 10000t など）を表します
+	ttmlOffsetTimeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|ms|m|s|f|t)$`)
+	// ttmlWhitespaceRegex はXMLの空白文字の連続を表します
+	ttmlWhitespaceRegex = regexp.MustCompile(`[ \t\r\n]+`)
+)
+
+// ttmlNode はTTMLドキュメントの要素またはテキストを表します
+type ttmlNode struct {
+	name     string // 要素のローカル名（テキストの場合は空）
+	attrs    map[string]string
+	text     string
+	line     int // 要素の開始タグの行番号（1始まり）
+	children []*ttmlNode
+}
+
+// attr は名前空間を無視してローカル名で属性値を返します
+func (n *ttmlNode) attr(name string) string {
+	return n.attrs[name]
+}
+
+// ttmlTiming はTTMLのパラメータ属性から決まる時間の単位を表します
+type ttmlTiming struct {
+	frameRate    float64 // 実効フレームレート（frameRateMultiplierを適用済み）
+	subFrameRate float64
+	tickRate     float64
+}
+
+// ttmlStyle はスタイル属性から決まる強調の種類を表します
+type ttmlStyle struct {
+	italic    bool
+	bold      bool
+	underline bool
+}
+
+// ttmlParser はTTMLドキュメントから字幕を組み立てる際の状態を保持します
+type ttmlParser struct {
+	timing ttmlTiming
+	agents map[string]string    // エージェントIDから話者名への対応
+	styles map[string]ttmlStyle // スタイルIDから強調の種類への対応
+	file   *VTTFile
+}
+
+// ParseTTML はリーダーからTTML形式のデータを解析します
+// ttm:agentで指定された話者は<v>タグ、斜体・太字・下線のスタイルは<i>, <b>, <u>タグとして保持します
+func ParseTTML(r io.Reader) (*VTTFile, error) {
+	root, err := readTTMLTree(r)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil || root.name != "tt" {
+		return nil, ErrInvalidTTML
+	}
+
+	timing, err := parseTTMLTiming(root)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &ttmlParser{
+		timing: timing,
+		agents: map[string]string{},
+		styles: map[string]ttmlStyle{},
+		file:   &VTTFile{Subtitles: []Subtitle{}},
+	}
+
+	for _, child := range root.children {
+		if child.name == "head" {
+			parser.readHead(child)
+		}
+	}
+	for _, child := range root.children {
+		if child.name == "body" {
+			if err := parser.walk(child, 0, time.Duration(math.MaxInt64), "", ttmlStyle{}); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return parser.file, nil
+}
+
+// readTTMLTree はXMLを読み込み、要素とテキストのツリーを作成します
+func readTTMLTree(r io.Reader) (*ttmlNode, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.CharsetReader = ttmlCharsetReader
+
+	var root *ttmlNode
+	var stack []*ttmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("TTMLの解析に失敗しました: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			line, _ := decoder.InputPos()
+			node := &ttmlNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr)), line: line}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = attr.Value
+			}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, &ttmlNode{text: string(t)})
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// parseTTMLTiming はtt要素のttp:frameRateなどのパラメータから時間の単位を決定します
+func parseTTMLTiming(root *ttmlNode) (ttmlTiming, error) {
+	timing := ttmlTiming{frameRate: 30, subFrameRate: 1, tickRate: 1}
+
+	frameRateSpecified := false
+	if value := root.attr("frameRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return timing, fmt.Errorf("ttp:frameRateが不正です: %s", value)
+		}
+		timing.frameRate = rate
+		frameRateSpecified = true
+	}
+
+	if value := root.attr("frameRateMultiplier"); value != "" {
+		parts := strings.Fields(value)
+		if len(parts) != 2 {
+			return timing, fmt.Errorf("ttp:frameRateMultiplierが不正です: %s", value)
+		}
+		numerator, err1 := strconv.ParseFloat(parts[0], 64)
+		denominator, err2 := strconv.ParseFloat(parts[1], 64)
+		if err1 != nil || err2 != nil || denominator == 0 {
+			return timing, fmt.Errorf("ttp:frameRateMultiplierが不正です: %s", value)
+		}
+		timing.frameRate *= numerator / denominator
+	}
+
+	if value := root.attr("subFrameRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return timing, fmt.Errorf("ttp:subFrameRateが不正です: %s", value)
+		}
+		timing.subFrameRate = rate
+	}
+
+	// tickRateの既定値はフレームレートが指定されている場合はフレームレート×サブフレームレート、それ以外は1
+	if frameRateSpecified {
+		timing.tickRate = timing.frameRate * timing.subFrameRate
+	}
+	if value := root.attr("tickRate"); value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate <= 0 {
+			return timing, fmt.Errorf("ttp:tickRateが不正です: %s", value)
+		}
+		timing.tickRate = rate
+	}
+
+	return timing, nil
+}
+
+// parseTime はTTMLの時間表現をtime.Durationに変換します
+func (t ttmlTiming) parseTime(expression string) (time.Duration, error) {
+	expression = strings.TrimSpace(expression)
+
+	if matches := ttmlClockTimeRegex.FindStringSubmatch(expression); matches != nil {
+		hours, _ := strconv.ParseFloat(matches[1], 64)
+		minutes, _ := strconv.ParseFloat(matches[2], 64)
+		seconds, _ := strconv.ParseFloat(matches[3], 64)
+		total := hours*3600 + minutes*60 + seconds
+
+		if matches[4] != "" {
+			fraction, _ := strconv.ParseFloat("0"+matches[4], 64)
+			total += fraction
+		}
+		if matches[5] != "" {
+			frames, _ := strconv.ParseFloat(matches[5], 64)
+			if matches[6] != "" {
+				subFrames, _ := strconv.ParseFloat(matches[6], 64)
+				frames += subFrames / t.subFrameRate
+			}
+			total += frames / t.frameRate
+		}
+		return secondsToDuration(total), nil
+	}
+
+	if matches := ttmlOffsetTimeRegex.FindStringSubmatch(expression); matches != nil {
+		value, _ := strconv.ParseFloat(matches[1], 64)
+		switch matches[2] {
+		case "h":
+			value *= 3600
+		case "m":
+			value *= 60
+		case "ms":
+			value /= 1000
+		case "f":
+			value /= t.frameRate
+		case "t":
+			value /= t.tickRate
+		}
+		return secondsToDuration(value), nil
+	}
+
+	return 0, fmt.Errorf("%w: %s", ErrInvalidTimestamp, expression)
+}
+
+// secondsToDuration は秒数をミリ秒単位に丸めたtime.Durationに変換します
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond
+}
+
+// readHead はhead要素からスタイルとエージェントの定義を読み込みます
+func (p *ttmlParser) readHead(head *ttmlNode) {
+	var visit func(node *ttmlNode)
+	visit = func(node *ttmlNode) {
+		switch node.name {
+		case "style":
+			if id := node.attr("id"); id != "" {
+				style := p.resolveStyle(node, ttmlStyle{})
+				p.styles[id] = style
+			}
+		case "agent":
+			if id := node.attr("id"); id != "" {
+				name := id
+				for _, child := range node.children {
+					if child.name == "name" {
+						if text := strings.TrimSpace(collectText(child)); text != "" {
+							name = text
+							break
+						}
+					}
+				}
+				p.agents[id] = name
+			}
+		}
+		for _, child := range node.children {
+			visit(child)
+		}
+	}
+	visit(head)
+}
+
+// resolveStyle は参照スタイルとインラインのスタイル属性を親のスタイルに重ねます
+func (p *ttmlParser) resolveStyle(node *ttmlNode, inherited ttmlStyle) ttmlStyle {
+	style := inherited
+	for _, ref := range strings.Fields(node.attr("style")) {
+		referenced := p.styles[ref]
+		style.italic = style.italic || referenced.italic
+		style.bold = style.bold || referenced.bold
+		style.underline = style.underline || referenced.underline
+	}
+	if value := node.attr("fontStyle"); value != "" {
+		style.italic = value == "italic" || value == "oblique"
+	}
+	if value := node.attr("fontWeight"); value != "" {
+		style.bold = value == "bold"
+	}
+	if value := node.attr("textDecoration"); value != "" {
+		style.underline = strings.Contains(value, "underline") && !strings.Contains(value, "noUnderline")
+	}
+	return style
+}
+
+// speaker はttm:agent属性から話者名を返します（未指定の場合は親の話者）
+func (p *ttmlParser) speaker(node *ttmlNode, inherited string) string {
+	agents := strings.Fields(node.attr("agent"))
+	if len(agents) == 0 {
+		return inherited
+	}
+	if name, ok := p.agents[agents[0]]; ok {
+		return name
+	}
+	return agents[0]
+}
+
+// nodeInterval は親の時間区間とbegin/end/dur属性から要素の時間区間を求めます
+// 子要素を順に再生するtimeContainer="seq"には対応していないため、エラーを返します
+func (p *ttmlParser) nodeInterval(node *ttmlNode, parentBegin, parentEnd time.Duration) (time.Duration, time.Duration, error) {
+	if node.attr("timeContainer") == "seq" {
+		return 0, 0, fmt.Errorf("%w: %d行目の<%s>要素のtimeContainer=\"seq\"には対応していません", ErrUnsupportedTTML, node.line, node.name)
+	}
+
+	begin, end := parentBegin, parentEnd
+
+	if value := node.attr("begin"); value != "" {
+		offset, err := p.timing.parseTime(value)
+		if err != nil {
+			return 0, 0, err
+		}
+		begin = parentBegin + offset
+	}
+
+	switch {
+	case node.attr("end") != "":
+		offset, err := p.timing.parseTime(node.attr("end"))
+		if err != nil {
+			return 0, 0, err
+		}
+		end = parentBegin + offset
+	case node.attr("dur") != "":
+		duration, err := p.timing.parseTime(node.attr("dur"))
+		if err != nil {
+			return 0, 0, err
+		}
+		end = begin + duration
+	}
+
+	if end > parentEnd {
+		end = parentEnd
+	}
+	return begin, end, nil
+}
+
+// walk はbody以下の要素をたどり、p要素を字幕に変換します
+func (p *ttmlParser) walk(node *ttmlNode, parentBegin, parentEnd time.Duration, speaker string, style ttmlStyle) error {
+	begin, end, err := p.nodeInterval(node, parentBegin, parentEnd)
+	if err != nil {
+		return err
+	}
+	speaker = p.speaker(node, speaker)
+	style = p.resolveStyle(node, style)
+
+	if node.name != "p" {
+		for _, child := range node.children {
+			if child.name == "" {
+				continue
+			}
+			if err := p.walk(child, begin, end, speaker, style); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// 時間が指定されていないp要素は表示されないため無視する
+	if end == time.Duration(math.MaxInt64) || end <= begin {
+		return nil
+	}
+
+	var builder strings.Builder
+	if err := p.writeCueText(&builder, node, begin, end, speaker, style); err != nil {
+		return err
+	}
+	text := strings.TrimSpace(strings.ReplaceAll(builder.String(), " \n", "\n"))
+	text = strings.ReplaceAll(text, "\n ", "\n")
+	if text == "" {
+		return nil
+	}
+	open, closing := styleTags(ttmlStyle{}, style)
+	text = open + text + closing
+	if speaker != "" {
+		text = "<v " + EscapeText(speaker) + ">" + text
+	}
+
+	styleName := ""
+	if refs := strings.Fields(node.attr("style")); len(refs) > 0 {
+		styleName = refs[0]
+	}
+
+	p.file.Subtitles = append(p.file.Subtitles, Subtitle{
+		ID:        node.attr("id"),
+		StartTime: begin,
+		EndTime:   end,
+		Text:      text,
+		Style:     styleName,
+		Line:      node.line,
+	})
+	return nil
+}
+
+// writeCueText はp要素の内容をWebVTTのキューテキストとして書き込みます
+// 話者の異なるspanは<v>タグ、開始時間が親要素より後のspanはキュー内のタイムスタンプとして表現します
+// spanの時間は親要素（p要素または外側のspan）の時間区間を基準に求めます
+func (p *ttmlParser) writeCueText(builder *strings.Builder, node *ttmlNode, parentBegin, parentEnd time.Duration, speaker string, style ttmlStyle) error {
+	for _, child := range node.children {
+		switch child.name {
+		case "":
+			builder.WriteString(EscapeText(ttmlWhitespaceRegex.ReplaceAllString(child.text, " ")))
+		case "br":
+			builder.WriteString("\n")
+		case "span":
+			begin, end, err := p.nodeInterval(child, parentBegin, parentEnd)
+			if err != nil {
+				return err
+			}
+			if begin > parentBegin {
+				builder.WriteString("<" + FormatTimestamp(begin) + ">")
+			}
+
+			childSpeaker := p.speaker(child, speaker)
+			childStyle := p.resolveStyle(child, style)
+			open, closing := styleTags(style, childStyle)
+			if childSpeaker != speaker {
+				open = "<v " + EscapeText(childSpeaker) + ">" + open
+				closing += "</v>"
+			}
+
+			builder.WriteString(open)
+			if err := p.writeCueText(builder, child, begin, end, childSpeaker, childStyle); err != nil {
+				return err
+			}
+			builder.WriteString(closing)
+		}
+	}
+	return nil
+}
+
+// styleTags は親のスタイルに対して新たに有効になった強調の開始タグと終了タグを返します
+func styleTags(parent, child ttmlStyle) (string, string) {
+	var open, closing string
+	if child.italic && !parent.italic {
+		open += "<i>"
+		closing = "</i>" + closing
+	}
+	if child.bold && !parent.bold {
+		open += "<b>"
+		closing = "</b>" + closing
+	}
+	if child.underline && !parent.underline {
+		open += "<u>"
+		closing = "</u>" + closing
+	}
+	return open, closing
+}
+
+// collectText は要素内のテキストを連結して返します
+func collectText(node *ttmlNode) string {
+	if node.name == "" {
+		return node.text
+	}
+	var builder strings.Builder
+	for _, child := range node.children {
+		builder.WriteString(collectText(child))
+	}
+	return builder.String()
+}
+
+// ttmlCharsetReader はXML宣言で指定された文字コードのデータをUTF-8に変換します
+// NewDecodingReaderなどですでにUTF-8に変換済みのデータはそのまま返します
+func ttmlCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	if utf8.Valid(data) {
+		return bytes.NewReader(data), nil
+	}
+	decoded, err := decodeBytes(data, charset)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(decoded), nil
+}
@@ -0,0 +1,100 @@
+package vtt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTTML(t *testing.T) {
+	type cue struct {
+		start time.Duration
+		end   time.Duration
+		text  string
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []cue
+	}{
+		{
+			name:  "offset and clock times",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml"><body><div><p begin="1.5s" end="00:00:03.000">Hello<br/>world</p></div></body></tt>`,
+			want:  []cue{{1500 * time.Millisecond, 3 * time.Second, "Hello\nworld"}},
+		},
+		{
+			name:  "frames and ticks",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="25" ttp:tickRate="10"><body><p begin="00:00:01:05" end="30t">A</p></body></tt>`,
+			want:  []cue{{1200 * time.Millisecond, 3 * time.Second, "A"}},
+		},
+		{
+			name:  "div offset and dur",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml"><body><div begin="10s"><p begin="1s" dur="2s">A</p></div></body></tt>`,
+			want:  []cue{{11 * time.Second, 13 * time.Second, "A"}},
+		},
+		{
+			name:  "untimed paragraph is ignored",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml"><body><p>A</p><p begin="1s" end="2s">B</p></body></tt>`,
+			want:  []cue{{time.Second, 2 * time.Second, "B"}},
+		},
+		{
+			name: "agents and styles",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling" xmlns:ttm="http://www.w3.org/ns/ttml#metadata">` +
+				`<head><styling><style xml:id="em" tts:fontStyle="italic"/></styling>` +
+				`<metadata><ttm:agent xml:id="a1"><ttm:name>Alice</ttm:name></ttm:agent></metadata></head>` +
+				`<body><p begin="0s" end="2s" ttm:agent="a1">Hi <span style="em">there</span> &amp; you</p></body></tt>`,
+			want: []cue{{0, 2 * time.Second, "<v Alice>Hi <i>there</i> &amp; you"}},
+		},
+		{
+			name:  "span timestamps",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml"><body><p begin="10s" end="20s"><span>One</span> <span begin="2s">two</span></p></body></tt>`,
+			want:  []cue{{10 * time.Second, 20 * time.Second, "One <00:00:12.000>two"}},
+		},
+		{
+			name:  "nested span is relative to parent span",
+			input: `<tt xmlns="http://www.w3.org/ns/ttml"><body><p begin="10s" end="20s">A <span begin="2s">B <span begin="1s">C</span></span></p></body></tt>`,
+			want:  []cue{{10 * time.Second, 20 * time.Second, "A <00:00:12.000>B <00:00:13.000>C"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseTTML(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d subtitles %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text {
+					t.Errorf("subtitle %d = {%v %v %q}, want {%v %v %q}", i, got.StartTime, got.EndTime, got.Text, want.start, want.end, want.text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTTMLErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  error
+	}{
+		{"not ttml", `<html><body/></html>`, ErrInvalidTTML},
+		{"invalid time", `<tt><body><p begin="soon" end="2s">A</p></body></tt>`, ErrInvalidTimestamp},
+		{"seq container", `<tt><body><div timeContainer="seq"><p dur="1s">A</p><p dur="1s">B</p></div></body></tt>`, ErrUnsupportedTTML},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTTML(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ParseTTML() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package vtt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 検証に使用するパターン
+var (
+	// looseTimestampRegex は修正案を作成するために、桁数や区切り文字の誤りを許容してタイムスタンプを表します
+	looseTimestampRegex = regexp.MustCompile(`^(?:(\d+):)?(\d+):(\d+)[.,](\d+)$`)
+	// cueTimestampTagRegex はキューテキスト内のタイムスタンプタグ（<00:00:01.500>）を表します
+	cueTimestampTagRegex = regexp.MustCompile(`<(\d[\d:.]*)>`)
+)
+
+// Severity は検証で見つかった問題の重大度を表します
+type Severity int
+
+const (
+	// SeverityError は字幕が正しく読み込めない問題を表します
+	SeverityError Severity = iota
+	// SeverityWarning は読み込めるが意図しない結果になる可能性がある問題を表します
+	SeverityWarning
+)
+
+// String はSeverityを文字列に変換します
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText はSeverityをJSONなどのテキスト表現に変換します
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// 検証で報告する問題の種類
+const (
+	CodeMissingHeader     = "missing-header"
+	CodeInvalidTimestamp  = "invalid-timestamp"
+	CodeEndBeforeStart    = "end-before-start"
+	CodeZeroDuration      = "zero-duration"
+	CodeUnorderedCue      = "unordered-cue"
+	CodeOverlap           = "overlap"
+	CodeEmptyText         = "empty-text"
+	CodeDuplicateID       = "duplicate-id"
+	CodeInvalidSetting    = "invalid-setting"
+	CodeStrayText         = "stray-text"
+	CodeMisplacedBlock    = "misplaced-block"
+	CodeInvalidNote       = "invalid-note"
+	CodeTimestampOutOfCue = "timestamp-out-of-cue"
+)
+
+// Diagnostic は検証で見つかった1つの問題を表します
+type Diagnostic struct {
+	Line       int      `json:"line,omitempty"`   // 行番号（1始まり、不明な場合は0）
+	Column     int      `json:"column,omitempty"` // 列番号（1始まりの文字単位、不明な場合は0）
+	Severity   Severity `json:"severity"`
+	Code       string   `json:"code"`
+	CueID      string   `json:"cue_id,omitempty"`
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// HasErrors は問題の中にエラーが含まれているかどうかを返します
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateOptions は検証の設定を表します
+type ValidateOptions struct {
+	// Strict は前のキューとの重なりをエラーとして報告します
+	// WebVTTではキューの重なりは正しい記述のため、通常は警告として報告します
+	Strict bool
+}
+
+// cueLocation は検証対象の字幕とファイル内の位置を表します
+type cueLocation struct {
+	subtitle    Subtitle
+	line        int // タイミング行の行番号（不明な場合は0）
+	startColumn int
+	endColumn   int
+}
+
+// Validate はリーダーから読み込んだ字幕データを検証し、見つかった問題を行番号順に返します
+// WebVTTは行と列の位置付きで検証し、その他の形式は解析後の字幕の内容を検証します
+// その他の形式の問題は字幕の行番号（TTMLは<p>要素の行）のみを報告し、列番号は0です
+// 読み込めずに無視された字幕は報告されず、JSON（Whisper）の問題は行番号も0になります
+func Validate(r io.Reader, format Format, options ValidateOptions) ([]Diagnostic, error) {
+	reader := bufio.NewReaderSize(r, sniffSize)
+
+	if format == FormatUnknown {
+		head, _ := reader.Peek(sniffSize)
+		format = DetectFormat(head)
+		// 形式を判別できない場合はWebVTTとして検証し、ヘッダーの問題として報告する
+		if format == FormatUnknown {
+			format = FormatVTT
+		}
+	}
+
+	if format == FormatVTT {
+		return validateVTT(reader, options)
+	}
+
+	vttFile, err := Parse(reader, format)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]cueLocation, 0, len(vttFile.Subtitles))
+	for _, subtitle := range vttFile.Subtitles {
+		locations = append(locations, cueLocation{subtitle: subtitle, line: subtitle.Line})
+	}
+	return sortDiagnostics(checkCues(locations, options)), nil
+}
+
+// validateVTT はWebVTTのデータをブロック単位で検証します
+func validateVTT(r io.Reader, options ValidateOptions) ([]Diagnostic, error) {
+	scanner := newLineScanner(r)
+	var diagnostics []Diagnostic
+
+	if !scanner.Scan() || !isVTTHeader(scanner.Text()) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:       1,
+			Column:     1,
+			Severity:   SeverityError,
+			Code:       CodeMissingHeader,
+			Message:    "ファイルがWEBVTTヘッダーで始まっていません",
+			Suggestion: "1行目に \"WEBVTT\" を記述してください",
+		})
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, blocks, err := readBlocks(scanner, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var locations []cueLocation
+	for _, b := range blocks {
+		first := b.lines[0]
+
+		switch {
+		case isBlockKeyword(first, "NOTE"):
+			for i, line := range b.lines {
+				if column := strings.Index(line, "-->"); column >= 0 {
+					diagnostics = append(diagnostics, Diagnostic{
+						Line:       b.line + i,
+						Column:     runeColumn(line, column),
+						Severity:   SeverityError,
+						Code:       CodeInvalidNote,
+						Message:    "NOTEブロックに\"-->\"を含めることはできません",
+						Suggestion: "コメントから\"-->\"を削除するか、NOTEブロックの前に空行を入れてください",
+					})
+				}
+			}
+
+		case isBlockKeyword(first, "STYLE") || isBlockKeyword(first, "REGION"):
+			if len(locations) > 0 {
+				keyword := strings.Fields(first)[0]
+				diagnostics = append(diagnostics, Diagnostic{
+					Line:       b.line,
+					Column:     1,
+					Severity:   SeverityWarning,
+					Code:       CodeMisplacedBlock,
+					Message:    fmt.Sprintf("最初のキューより後にある%sブロックは無視されます", keyword),
+					Suggestion: fmt.Sprintf("%sブロックを最初のキューより前に移動してください", keyword),
+				})
+			}
+
+		default:
+			location, blockDiagnostics := validateCueBlock(b)
+			diagnostics = append(diagnostics, blockDiagnostics...)
+			if location != nil {
+				locations = append(locations, *location)
+			}
+		}
+	}
+
+	diagnostics = append(diagnostics, checkCues(locations, options)...)
+	return sortDiagnostics(diagnostics), nil
+}
+
+// validateCueBlock はキューブロックのタイミング行・設定・テキストを検証します
+// タイミング行を解析できた場合はキューの位置を返します
+func validateCueBlock(b block) (*cueLocation, []Diagnostic) {
+	var diagnostics []Diagnostic
+
+	// タイミング行を探す（1行目またはキュー識別子の次の行）
+	timingIndex := -1
+	for i := 0; i < len(b.lines) && i < 2; i++ {
+		if strings.Contains(b.lines[i], "-->") {
+			timingIndex = i
+			break
+		}
+	}
+	if timingIndex < 0 {
+		return nil, []Diagnostic{{
+			Line:       b.line,
+			Column:     1,
+			Severity:   SeverityWarning,
+			Code:       CodeStrayText,
+			Message:    "タイミング行のないブロックは無視されます",
+			Suggestion: "テキストの前に \"00:00:00.000 --> 00:00:01.000\" の形式のタイミング行を追加するか、前のキューとの間の空行を削除してください",
+		}}
+	}
+
+	id := ""
+	if timingIndex == 1 {
+		id = strings.TrimSpace(b.lines[0])
+	}
+	lineNumber := b.line + timingIndex
+	line := b.lines[timingIndex]
+
+	// タイムスタンプを個別に検証して位置を特定する
+	arrow := strings.Index(line, "-->")
+	startRaw, startOffset := trimmedField(line[:arrow], 0)
+	endRaw, endOffset := trimmedField(line[arrow+3:], arrow+3)
+	settingsRaw := ""
+	settingsOffset := 0
+	if endRaw != "" {
+		settingsOffset = endOffset + len(endRaw)
+		settingsRaw = line[settingsOffset:]
+	}
+
+	startTime, startErr := parseTimestamp(startRaw)
+	endTime, endErr := parseTimestamp(endRaw)
+	for _, ts := range []struct {
+		raw    string
+		offset int
+		err    error
+		label  string
+	}{
+		{startRaw, startOffset, startErr, "開始"},
+		{endRaw, endOffset, endErr, "終了"},
+	} {
+		if ts.err == nil {
+			continue
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:       lineNumber,
+			Column:     runeColumn(line, ts.offset),
+			Severity:   SeverityError,
+			Code:       CodeInvalidTimestamp,
+			CueID:      id,
+			Message:    fmt.Sprintf("%s時間のタイムスタンプ %q が不正なため、このキューは無視されます", ts.label, ts.raw),
+			Suggestion: suggestTimestamp(ts.raw),
+		})
+	}
+	if startErr != nil || endErr != nil {
+		return nil, diagnostics
+	}
+
+	// 無視されるキュー設定を報告する
+	for _, setting := range invalidCueSettings(settingsRaw) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Line:       lineNumber,
+			Column:     runeColumn(line, settingsOffset+strings.Index(settingsRaw, setting)),
+			Severity:   SeverityWarning,
+			Code:       CodeInvalidSetting,
+			CueID:      id,
+			Message:    fmt.Sprintf("キュー設定 %q は未知の設定か不正な値のため無視されます", setting),
+			Suggestion: "vertical, line, position, size, align, region のいずれかを \"名前:値\" の形式で指定してください",
+		})
+	}
+
+	textLines := b.lines[timingIndex+1:]
+	subtitle := Subtitle{
+		ID:        id,
+		StartTime: startTime,
+		EndTime:   endTime,
+		Text:      strings.Join(textLines, "\n"),
+		Settings:  parseCueSettings(settingsRaw),
+	}
+
+	// キュー内のタイムスタンプがキューの区間内にあるかを確認する
+	for i, textLine := range textLines {
+		for _, loc := range cueTimestampTagRegex.FindAllStringSubmatchIndex(textLine, -1) {
+			timestamp, err := parseTimestamp(textLine[loc[2]:loc[3]])
+			if err != nil || (timestamp > startTime && timestamp < endTime) {
+				continue
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       lineNumber + 1 + i,
+				Column:     runeColumn(textLine, loc[0]),
+				Severity:   SeverityWarning,
+				Code:       CodeTimestampOutOfCue,
+				CueID:      id,
+				Message:    fmt.Sprintf("キュー内のタイムスタンプ %s がキューの区間（%s --> %s）の外にあります", textLine[loc[2]:loc[3]], FormatTimestamp(startTime), FormatTimestamp(endTime)),
+				Suggestion: "キューの開始時間より後、終了時間より前の時刻を指定してください",
+			})
+		}
+	}
+
+	return &cueLocation{
+		subtitle:    subtitle,
+		line:        lineNumber,
+		startColumn: runeColumn(line, startOffset),
+		endColumn:   runeColumn(line, endOffset),
+	}, diagnostics
+}
+
+// checkCues は字幕の時間の前後関係、重なり、空のテキスト、識別子の重複を検証します
+func checkCues(locations []cueLocation, options ValidateOptions) []Diagnostic {
+	var diagnostics []Diagnostic
+	overlapSeverity := SeverityWarning
+	if options.Strict {
+		overlapSeverity = SeverityError
+	}
+	seenIDs := map[string]int{}
+
+	for i, location := range locations {
+		subtitle := location.subtitle
+		id := subtitle.ID
+		label := fmt.Sprintf("キュー #%d", i+1)
+		if id != "" {
+			label += fmt.Sprintf(" (ID: %s)", id)
+		}
+
+		switch {
+		case subtitle.EndTime < subtitle.StartTime:
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       location.line,
+				Column:     location.endColumn,
+				Severity:   SeverityError,
+				Code:       CodeEndBeforeStart,
+				CueID:      id,
+				Message:    fmt.Sprintf("%s の終了時間 %s が開始時間 %s より前です", label, FormatTimestamp(subtitle.EndTime), FormatTimestamp(subtitle.StartTime)),
+				Suggestion: "開始時間と終了時間が入れ替わっていないか確認してください",
+			})
+		case subtitle.EndTime == subtitle.StartTime:
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       location.line,
+				Column:     location.endColumn,
+				Severity:   SeverityWarning,
+				Code:       CodeZeroDuration,
+				CueID:      id,
+				Message:    fmt.Sprintf("%s の長さが0です", label),
+				Suggestion: "終了時間を開始時間より後にしてください",
+			})
+		}
+
+		if strings.TrimSpace(subtitle.PlainText()) == "" {
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       location.line,
+				Column:     location.startColumn,
+				Severity:   SeverityError,
+				Code:       CodeEmptyText,
+				CueID:      id,
+				Message:    fmt.Sprintf("%s のテキストが空のため、読み上げられません", label),
+				Suggestion: "タイミング行の次の行にテキストを記述するか、キューを削除してください",
+			})
+		}
+
+		if id != "" {
+			if previous, ok := seenIDs[id]; ok {
+				// WebVTTのキュー識別子はタイミング行の前の行にある
+				// その他の形式では字幕の位置を報告する
+				idLine, idColumn := location.line-1, 1
+				if location.startColumn == 0 {
+					idLine, idColumn = location.line, 0
+				}
+				diagnostics = append(diagnostics, Diagnostic{
+					Line:       idLine,
+					Column:     idColumn,
+					Severity:   SeverityWarning,
+					Code:       CodeDuplicateID,
+					CueID:      id,
+					Message:    fmt.Sprintf("キュー識別子 %q はキュー #%d と重複しています", id, previous+1),
+					Suggestion: "キュー識別子をファイル内で一意にしてください",
+				})
+			} else {
+				seenIDs[id] = i
+			}
+		}
+
+		if i == 0 {
+			continue
+		}
+		previous := locations[i-1].subtitle
+
+		if subtitle.StartTime < previous.StartTime {
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       location.line,
+				Column:     location.startColumn,
+				Severity:   SeverityError,
+				Code:       CodeUnorderedCue,
+				CueID:      id,
+				Message:    fmt.Sprintf("%s の開始時間 %s が前のキューの開始時間 %s より前です", label, FormatTimestamp(subtitle.StartTime), FormatTimestamp(previous.StartTime)),
+				Suggestion: "キューを開始時間の順に並べ替えてください",
+			})
+		} else if subtitle.StartTime < previous.EndTime {
+			diagnostics = append(diagnostics, Diagnostic{
+				Line:       location.line,
+				Column:     location.startColumn,
+				Severity:   overlapSeverity,
+				Code:       CodeOverlap,
+				CueID:      id,
+				Message:    fmt.Sprintf("%s が前のキューと %s 重なっています", label, previous.EndTime-subtitle.StartTime),
+				Suggestion: fmt.Sprintf("開始時間を %s 以降にするか、前のキューの終了時間を %s 以前にしてください", FormatTimestamp(previous.EndTime), FormatTimestamp(subtitle.StartTime)),
+			})
+		}
+	}
+
+	return diagnostics
+}
+
+// suggestTimestamp は不正なタイムスタンプの修正案を返します
+func suggestTimestamp(raw string) string {
+	const format = "HH:MM:SS.mmm または MM:SS.mmm の形式で記述してください（例: 00:01:02.500）"
+
+	matches := looseTimestampRegex.FindStringSubmatch(raw)
+	if matches == nil {
+		return format
+	}
+
+	hours, _ := strconv.Atoi(matches[1])
+	minutes, _ := strconv.Atoi(matches[2])
+	seconds, _ := strconv.Atoi(matches[3])
+	milliseconds, _ := strconv.Atoi((matches[4] + "00")[:3])
+	if minutes >= 60 || seconds >= 60 {
+		return "分と秒は00から59の範囲で記述してください。" + format
+	}
+
+	duration := time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(milliseconds)*time.Millisecond
+	return fmt.Sprintf("%q のように記述してください", FormatTimestamp(duration))
+}
+
+// trimmedField は文字列から前後の空白を除いた最初のフィールドと、その行内での開始位置を返します
+func trimmedField(text string, offset int) (string, int) {
+	trimmed := strings.TrimLeft(text, " \t")
+	offset += len(text) - len(trimmed)
+	if i := strings.IndexAny(trimmed, " \t"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	return trimmed, offset
+}
+
+// runeColumn はバイト位置を1始まりの文字単位の列番号に変換します
+func runeColumn(line string, byteOffset int) int {
+	if byteOffset > len(line) {
+		byteOffset = len(line)
+	}
+	return utf8.RuneCountInString(line[:byteOffset]) + 1
+}
+
+// sortDiagnostics は問題を行番号と列番号の順に並べ替えます
+func sortDiagnostics(diagnostics []Diagnostic) []Diagnostic {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		if diagnostics[i].Line != diagnostics[j].Line {
+			return diagnostics[i].Line < diagnostics[j].Line
+		}
+		return diagnostics[i].Column < diagnostics[j].Column
+	})
+	return diagnostics
+}
@@ -0,0 +1,185 @@
+package vtt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	type want struct {
+		line     int
+		column   int
+		severity Severity
+		code     string
+	}
+
+	tests := []struct {
+		name    string
+		format  Format
+		options ValidateOptions
+		input   string
+		want    []want
+	}{
+		{
+			name:   "valid",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+		},
+		{
+			name:   "missing header",
+			format: FormatVTT,
+			input:  "00:00:01.000 --> 00:00:02.000\nHello\n",
+			want:   []want{{1, 1, SeverityError, CodeMissingHeader}},
+		},
+		{
+			name:   "invalid timestamp",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01,000 --> 00:00:02.000\nHello\n",
+			want:   []want{{3, 1, SeverityError, CodeInvalidTimestamp}},
+		},
+		{
+			name:   "end before start",
+			format: FormatVTT,
+			input:  "WEBVTT\n\ncue\n00:00:03.000 --> 00:00:02.000\nHello\n",
+			want:   []want{{4, 18, SeverityError, CodeEndBeforeStart}},
+		},
+		{
+			name:   "zero duration",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:02.000 --> 00:00:02.000\nHello\n",
+			want:   []want{{3, 18, SeverityWarning, CodeZeroDuration}},
+		},
+		{
+			name:   "overlap",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\nA\n\n00:00:02.000 --> 00:00:04.000\nB\n",
+			want:   []want{{6, 1, SeverityWarning, CodeOverlap}},
+		},
+		{
+			name:   "unordered",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:05.000 --> 00:00:06.000\nA\n\n00:00:02.000 --> 00:00:03.000\nB\n",
+			want:   []want{{6, 1, SeverityError, CodeUnorderedCue}},
+		},
+		{
+			name:   "empty text",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\n<i></i>\n",
+			want:   []want{{3, 1, SeverityError, CodeEmptyText}},
+		},
+		{
+			name:   "duplicate id",
+			format: FormatVTT,
+			input:  "WEBVTT\n\na\n00:00:01.000 --> 00:00:02.000\nA\n\na\n00:00:03.000 --> 00:00:04.000\nB\n",
+			want:   []want{{7, 1, SeverityWarning, CodeDuplicateID}},
+		},
+		{
+			name:   "invalid setting",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000 align:middle\nHello\n",
+			want:   []want{{3, 31, SeverityWarning, CodeInvalidSetting}},
+		},
+		{
+			name:   "stray text",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n\nstray\n",
+			want:   []want{{6, 1, SeverityWarning, CodeStrayText}},
+		},
+		{
+			name:   "arrow in note",
+			format: FormatVTT,
+			input:  "WEBVTT\n\nNOTE a --> b\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+			want:   []want{{3, 8, SeverityError, CodeInvalidNote}},
+		},
+		{
+			name:   "misplaced style",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n\nSTYLE\n::cue { color: red }\n",
+			want:   []want{{6, 1, SeverityWarning, CodeMisplacedBlock}},
+		},
+		{
+			name:   "timestamp out of cue",
+			format: FormatVTT,
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nA <00:00:05.000>B\n",
+			want:   []want{{4, 3, SeverityWarning, CodeTimestampOutOfCue}},
+		},
+		{
+			name:   "srt overlap reports line",
+			format: FormatSRT,
+			input:  "1\n00:00:01,000 --> 00:00:03,000\nA\n\n2\n00:00:02,000 --> 00:00:04,000\nB\n",
+			want:   []want{{6, 0, SeverityWarning, CodeOverlap}},
+		},
+		{
+			name:   "sbv end before start reports line",
+			format: FormatSBV,
+			input:  "0:00:03.000,0:00:02.000\nA\n",
+			want:   []want{{1, 0, SeverityError, CodeEndBeforeStart}},
+		},
+		{
+			name:   "ass overlap reports line",
+			format: FormatASS,
+			input:  "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\nDialogue: 0,0:00:01.00,0:00:03.00,Default,,0,0,0,,A\nDialogue: 0,0:00:02.00,0:00:04.00,Default,,0,0,0,,B\n",
+			want:   []want{{4, 0, SeverityWarning, CodeOverlap}},
+		},
+		{
+			name:   "ttml overlap reports line",
+			format: FormatTTML,
+			input:  "<tt xmlns=\"http://www.w3.org/ns/ttml\"><body><div>\n<p begin=\"1s\" end=\"3s\">A</p>\n<p begin=\"2s\" end=\"4s\">B</p>\n</div></body></tt>",
+			want:   []want{{3, 0, SeverityWarning, CodeOverlap}},
+		},
+		{
+			name:    "strict overlap is an error",
+			format:  FormatVTT,
+			options: ValidateOptions{Strict: true},
+			input:   "WEBVTT\n\n00:00:01.000 --> 00:00:03.000\nA\n\n00:00:02.000 --> 00:00:04.000\nB\n",
+			want:    []want{{6, 1, SeverityError, CodeOverlap}},
+		},
+		{
+			name:   "json has no position",
+			format: FormatTranscript,
+			input:  `{"segments": [{"start": 1, "end": 3, "text": "A"}, {"start": 2, "end": 4, "text": "B"}]}`,
+			want:   []want{{0, 0, SeverityWarning, CodeOverlap}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics, err := Validate(strings.NewReader(tt.input), tt.format, tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(diagnostics) != len(tt.want) {
+				t.Fatalf("got %d diagnostics %+v, want %d", len(diagnostics), diagnostics, len(tt.want))
+			}
+			for i, w := range tt.want {
+				got := diagnostics[i]
+				if got.Line != w.line || got.Column != w.column || got.Severity != w.severity || got.Code != w.code {
+					t.Errorf("diagnostic %d = %d:%d %s[%s], want %d:%d %s[%s]",
+						i, got.Line, got.Column, got.Severity, got.Code, w.line, w.column, w.severity, w.code)
+				}
+				if got.Suggestion == "" && got.Code != CodeZeroDuration {
+					t.Errorf("diagnostic %d has no suggestion", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestTimestamp(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"00:00:01,500", `"00:00:01.500" のように記述してください`},
+		{"1:2:3.4", `"01:02:03.400" のように記述してください`},
+		{"00:61:00.000", "分と秒は00から59の範囲で記述してください。HH:MM:SS.mmm または MM:SS.mmm の形式で記述してください（例: 00:01:02.500）"},
+		{"abc", "HH:MM:SS.mmm または MM:SS.mmm の形式で記述してください（例: 00:01:02.500）"},
+	}
+
+	for _, tt := range tests {
+		if got := suggestTimestamp(tt.raw); got != tt.want {
+			t.Errorf("suggestTimestamp(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package vtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTranscript はJSONトランスクリプトにセグメントがない場合のエラーです
+var ErrInvalidTranscript = errors.New("invalid JSON transcript: missing segments")
+
+// transcriptWord はトランスクリプトの単語とそのタイミングを表します
+type transcriptWord struct {
+	Word  string   `json:"word"`
+	Start *float64 `json:"start"`
+	End   *float64 `json:"end"`
+}
+
+// transcriptSegment はトランスクリプトのセグメント（1つの発話）を表します
+type transcriptSegment struct {
+	ID      any              `json:"id"`
+	Start   float64          `json:"start"`
+	End     float64          `json:"end"`
+	Text    string           `json:"text"`
+	Speaker string           `json:"speaker"`
+	Words   []transcriptWord `json:"words"`
+}
+
+// ParseTranscript はリーダーからJSONトランスクリプトを解析します
+// {"segments": [...]}形式とセグメントの配列のみの形式の両方を受け付けます
+// 単語のタイミングはキュー内のタイムスタンプ、speakerは<v>タグとして保持します
+func ParseTranscript(r io.Reader) (*VTTFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []transcriptSegment
+	if trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, byteOrderMark)); bytes.HasPrefix(trimmed, []byte("[")) {
+		if err := json.Unmarshal(trimmed, &segments); err != nil {
+			return nil, fmt.Errorf("JSONトランスクリプトの解析に失敗しました: %w", err)
+		}
+	} else {
+		var transcript struct {
+			Segments []transcriptSegment `json:"segments"`
+		}
+		if err := json.Unmarshal(trimmed, &transcript); err != nil {
+			return nil, fmt.Errorf("JSONトランスクリプトの解析に失敗しました: %w", err)
+		}
+		if transcript.Segments == nil {
+			return nil, ErrInvalidTranscript
+		}
+		segments = transcript.Segments
+	}
+
+	vttFile := &VTTFile{Subtitles: make([]Subtitle, 0, len(segments))}
+	for _, segment := range segments {
+		startTime := secondsToDuration(segment.Start)
+		endTime := secondsToDuration(segment.End)
+
+		text := transcriptCueText(segment, startTime, endTime)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if segment.Speaker != "" {
+			text = "<v " + EscapeText(segment.Speaker) + ">" + text
+		}
+
+		vttFile.Subtitles = append(vttFile.Subtitles, Subtitle{
+			ID:        transcriptID(segment.ID),
+			StartTime: startTime,
+			EndTime:   endTime,
+			Text:      text,
+		})
+	}
+
+	return vttFile, nil
+}
+
+// transcriptID はセグメントのIDをキュー識別子に変換します
+// 数値のIDは指数表記にせず、整数の場合は小数点なしで表します（12 → "12"）
+func transcriptID(value any) string {
+	switch id := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	default:
+		return fmt.Sprint(id)
+	}
+}
+
+// transcriptCueText はセグメントのテキストをキューテキストに変換します
+// 単語のタイミングがある場合は、キューの区間内の単語の前にタイムスタンプを挿入します
+func transcriptCueText(segment transcriptSegment, startTime, endTime time.Duration) string {
+	if len(segment.Words) == 0 {
+		return EscapeText(strings.TrimSpace(segment.Text))
+	}
+
+	var builder strings.Builder
+	for i, word := range segment.Words {
+		text := word.Word
+		if i == 0 {
+			text = strings.TrimLeft(text, " ")
+		}
+		if word.Start != nil {
+			wordStart := secondsToDuration(*word.Start)
+			if wordStart > startTime && wordStart < endTime {
+				// タイムスタンプは単語の前の空白の後に置く
+				trimmed := strings.TrimLeft(text, " ")
+				builder.WriteString(text[:len(text)-len(trimmed)])
+				builder.WriteString("<" + FormatTimestamp(wordStart) + ">")
+				text = trimmed
+			}
+		}
+		builder.WriteString(EscapeText(text))
+	}
+
+	return strings.TrimSpace(builder.String())
+}
@@ -0,0 +1,82 @@
+package vtt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTranscript(t *testing.T) {
+	type cue struct {
+		id    string
+		start time.Duration
+		end   time.Duration
+		text  string
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []cue
+	}{
+		{
+			name:  "segments object",
+			input: `{"text": "Hello", "segments": [{"id": 0, "start": 0.5, "end": 2.25, "text": " Hello & bye"}]}`,
+			want:  []cue{{"0", 500 * time.Millisecond, 2250 * time.Millisecond, "Hello &amp; bye"}},
+		},
+		{
+			name:  "array with whitespace",
+			input: "[\n  {\"id\": \"a\", \"start\": 1, \"end\": 2, \"text\": \"A\"}\n]",
+			want:  []cue{{"a", time.Second, 2 * time.Second, "A"}},
+		},
+		{
+			name:  "large and fractional ids",
+			input: `[{"id": 12345678, "start": 1, "end": 2, "text": "A"}, {"id": 1.5, "start": 2, "end": 3, "text": "B"}]`,
+			want: []cue{
+				{"12345678", time.Second, 2 * time.Second, "A"},
+				{"1.5", 2 * time.Second, 3 * time.Second, "B"},
+			},
+		},
+		{
+			name:  "speaker",
+			input: `[{"start": 0, "end": 1, "text": "Hi", "speaker": "SPEAKER_00"}]`,
+			want:  []cue{{"", 0, time.Second, "<v SPEAKER_00>Hi"}},
+		},
+		{
+			name:  "word timestamps",
+			input: `[{"start": 0, "end": 2, "text": "Hi there", "words": [{"word": " Hi", "start": 0, "end": 0.5}, {"word": " there", "start": 1, "end": 2}]}]`,
+			want:  []cue{{"", 0, 2 * time.Second, "Hi <00:00:01.000>there"}},
+		},
+		{
+			name:  "empty segment is skipped",
+			input: `[{"start": 0, "end": 1, "text": "  "}, {"start": 1, "end": 2, "text": "B"}]`,
+			want:  []cue{{"", time.Second, 2 * time.Second, "B"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseTranscript(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(file.Subtitles) != len(tt.want) {
+				t.Fatalf("got %d subtitles %+v, want %d", len(file.Subtitles), file.Subtitles, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := file.Subtitles[i]
+				if got.ID != want.id || got.StartTime != want.start || got.EndTime != want.end || got.Text != want.text {
+					t.Errorf("subtitle %d = {%q %v %v %q}, want {%q %v %v %q}",
+						i, got.ID, got.StartTime, got.EndTime, got.Text, want.id, want.start, want.end, want.text)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTranscriptMissingSegments(t *testing.T) {
+	if _, err := ParseTranscript(strings.NewReader(`{"text": "Hello"}`)); !errors.Is(err, ErrInvalidTranscript) {
+		t.Errorf("ParseTranscript() error = %v, want %v", err, ErrInvalidTranscript)
+	}
+}
@@ -0,0 +1,143 @@
+package vtt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteVTTFile はVTTFile構造体をWebVTT形式でファイルに書き込みます
+func WriteVTTFile(filePath string, vttFile *VTTFile) (err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		closeErr := file.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return WriteVTT(file, vttFile)
+}
+
+// WriteVTT はVTTFile構造体をWebVTT形式でライターに書き込みます
+// ParseVTTで読み込んだ内容（ヘッダー、NOTE/STYLE/REGIONブロック、キュー識別子、キュー設定、
+// キューテキストのマークアップ）はそのまま書き出されるため、再度解析すると同じ内容になります
+func WriteVTT(w io.Writer, vttFile *VTTFile) error {
+	writer := bufio.NewWriter(w)
+
+	// ヘッダー行と、それに続くヘッダーの行を書き込む
+	writer.WriteString(vttHeader)
+	if header := sanitizeCueID(vttFile.Header); header != "" {
+		writer.WriteString(" " + header)
+	}
+	writer.WriteString("\n")
+	if headerLines := sanitizeBlockText(strings.Join(vttFile.HeaderLines, "\n")); headerLines != "" {
+		writer.WriteString(headerLines + "\n")
+	}
+
+	// 最初のキューより前のコメントを書き込む
+	notes := vttFile.Notes
+	for len(notes) > 0 && notes[0].CueIndex <= 0 {
+		writeNote(writer, notes[0])
+		notes = notes[1:]
+	}
+
+	// STYLEとREGIONは最初のキューより前に置く必要がある
+	for _, style := range vttFile.Styles {
+		fmt.Fprintf(writer, "\nSTYLE\n%s\n", sanitizeBlockText(style.CSS))
+	}
+	for _, region := range vttFile.Regions {
+		fmt.Fprintf(writer, "\nREGION\n%s\n", region)
+	}
+
+	for i, subtitle := range vttFile.Subtitles {
+		writer.WriteString("\n")
+		if subtitle.ID != "" {
+			writer.WriteString(sanitizeCueID(subtitle.ID) + "\n")
+		}
+		writer.WriteString(FormatTimestamp(subtitle.StartTime) + " --> " + FormatTimestamp(subtitle.EndTime))
+		if !subtitle.Settings.IsZero() {
+			writer.WriteString(" " + subtitle.Settings.String())
+		}
+		writer.WriteString("\n")
+		if text := sanitizeBlockText(subtitle.Text); text != "" {
+			writer.WriteString(text + "\n")
+		}
+
+		// このキューの後に現れたコメントを書き込む
+		for len(notes) > 0 && notes[0].CueIndex <= i+1 {
+			writeNote(writer, notes[0])
+			notes = notes[1:]
+		}
+	}
+
+	// キューの数を超える位置のコメントは末尾に書き込む
+	for _, note := range notes {
+		writeNote(writer, note)
+	}
+
+	return writer.Flush()
+}
+
+// writeNote はNOTEブロックを書き込みます
+// 1行のコメントはNOTEと同じ行に、複数行のコメントは次の行から書き込みます
+// NOTEブロックは"-->"を含んでも解析できるため、コメントは空行以外そのまま書き込みます
+func writeNote(writer *bufio.Writer, note Note) {
+	text := removeBlankLines(note.Text)
+	switch {
+	case text == "":
+		writer.WriteString("\nNOTE\n")
+	case strings.Contains(text, "\n"):
+		writer.WriteString("\nNOTE\n" + text + "\n")
+	default:
+		writer.WriteString("\nNOTE " + text + "\n")
+	}
+}
+
+// sanitizeBlockText はブロック内に書き込めない空行と"-->"を取り除きます
+// 空行はブロックの区切り、"-->"はタイミング行として解析されてしまうためです
+func sanitizeBlockText(text string) string {
+	return removeBlankLines(strings.ReplaceAll(text, "-->", "--&gt;"))
+}
+
+// removeBlankLines はブロックの区切りとして解析されてしまう空行を取り除きます
+func removeBlankLines(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// sanitizeCueID はキュー識別子やヘッダー行のテキストとして書き込めない改行と"-->"を取り除きます
+func sanitizeCueID(id string) string {
+	id = strings.Join(strings.Fields(strings.ReplaceAll(id, "\n", " ")), " ")
+	return strings.ReplaceAll(id, "-->", "->")
+}
+
+// FormatTimestamp は時間をWebVTTのタイムスタンプ形式（HH:MM:SS.mmm）に変換します
+// 時間は2桁以上で、100時間以上の場合は桁数を増やして書き込みます。負の時間は0として扱います
+func FormatTimestamp(duration time.Duration) string {
+	if duration < 0 {
+		duration = 0
+	}
+
+	milliseconds := duration.Milliseconds()
+	hours := milliseconds / int64(time.Hour/time.Millisecond)
+	minutes := milliseconds / int64(time.Minute/time.Millisecond) % 60
+	seconds := milliseconds / int64(time.Second/time.Millisecond) % 60
+
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", hours, minutes, seconds, milliseconds%1000)
+}
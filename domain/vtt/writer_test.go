@@ -0,0 +1,102 @@
+package vtt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteVTTRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "minimal",
+			input: "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+		},
+		{
+			name:  "header text and header lines",
+			input: "WEBVTT - Translation\nKind: captions\nLanguage: ja\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+		},
+		{
+			name:  "header lines only",
+			input: "WEBVTT\nKind: captions\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+		},
+		{
+			name: "blocks, ids, settings and markup",
+			input: "WEBVTT\n\nNOTE first\n\nSTYLE\n::cue { color: yellow }\n\nREGION\nid:fred width:40%\n\n" +
+				"intro\n00:00:01.000 --> 00:00:02.000 line:10% align:start\n<v Alice><i>Hello</i> &amp; welcome\n\n" +
+				"NOTE\nmulti\nline\n\n" +
+				"00:00:03.000 --> 00:00:04.000\nBye\n\nNOTE trailing\n",
+		},
+		{
+			name:  "note with arrow is kept",
+			input: "WEBVTT\n\nNOTE a --> b\n\n00:00:01.000 --> 00:00:02.000\nHello\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseVTT(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var written bytes.Buffer
+			if err := WriteVTT(&written, file); err != nil {
+				t.Fatal(err)
+			}
+			if written.String() != tt.input {
+				t.Errorf("WriteVTT() =\n%s\nwant\n%s", written.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestWriteVTTSanitizes(t *testing.T) {
+	file := &VTTFile{
+		Header:      "a\nb",
+		HeaderLines: []string{"Kind: captions", "", "x --> y"},
+		Subtitles: []Subtitle{
+			{ID: "a --> b", StartTime: time.Second, EndTime: 2 * time.Second, Text: "one\n\ntwo --> three"},
+		},
+	}
+
+	var written bytes.Buffer
+	if err := WriteVTT(&written, file); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "WEBVTT a b\nKind: captions\nx --&gt; y\n\na -> b\n00:00:01.000 --> 00:00:02.000\none\ntwo --&gt; three\n"
+	if written.String() != want {
+		t.Errorf("WriteVTT() = %q, want %q", written.String(), want)
+	}
+
+	reparsed, err := ParseVTT(&written)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reparsed.Subtitles) != 1 || reparsed.Subtitles[0].PlainText() != "one\ntwo --> three" {
+		t.Errorf("reparsed subtitles = %+v", reparsed.Subtitles)
+	}
+}
+
+func TestFormatTimestamp(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "00:00:00.000"},
+		{-time.Second, "00:00:00.000"},
+		{time.Hour + 2*time.Minute + 3*time.Second + 45*time.Millisecond, "01:02:03.045"},
+		{123 * time.Hour, "123:00:00.000"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTimestamp(tt.duration); got != tt.want {
+			t.Errorf("FormatTimestamp(%v) = %q, want %q", tt.duration, got, tt.want)
+		}
+	}
+}
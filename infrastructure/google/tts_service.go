@@ -2,29 +2,66 @@ package google
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 	"vtt2mp3/domain/audio"
 	"vtt2mp3/domain/tts"
 
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/googleapis/gax-go/v2"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// unsafeFileNameRegex はファイル名に使用できない文字を表します
+var unsafeFileNameRegex = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// maxFileNameIDLength は一時ファイル名に含めるキュー識別子の最大長です
+const maxFileNameIDLength = 32
+
+// maxFitAttempts は話す速さを上げて表示時間に合わせる際に、合成し直す最大の回数です
+const maxFitAttempts = 3
+
+// 並行して音声を合成する際の既定値
+const (
+	// defaultWorkers は並行して合成するリクエストの数です
+	defaultWorkers = 8
+	// defaultRequestsPerSecond は1秒あたりのリクエスト数の上限です
+	// Text-to-Speech APIの既定の割り当て（1分あたり1,000リクエスト）に収まるように設定しています
+	defaultRequestsPerSecond = 15
+)
+
+// synthesisSession は1回の複数の音声合成で共有するリクエスト数の制限と再試行の設定を表します
+type synthesisSession struct {
+	limiter *rate.Limiter
+	retry   tts.RetryOptions
+}
+
+// speechClient はTextToSpeechServiceが使用するGoogle Cloud Text-to-Speech APIのクライアントの機能です
+type speechClient interface {
+	SynthesizeSpeech(ctx context.Context, req *texttospeechpb.SynthesizeSpeechRequest, opts ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error)
+	ListVoices(ctx context.Context, req *texttospeechpb.ListVoicesRequest, opts ...gax.CallOption) (*texttospeechpb.ListVoicesResponse, error)
+}
+
 // TextToSpeechService はGoogle Cloud Text-to-Speech APIを使用してtts.TextToSpeechServiceインターフェースを実装します
 type TextToSpeechService struct {
-	client         *texttospeech.Client
-	ctx            context.Context
+	client         speechClient
 	audioProcessor *audio.AudioProcessor
+	// audioDuration は音声ファイルの長さを取得します（通常はaudioProcessor.GetAudioDuration）
+	audioDuration func(ctx context.Context, audioFile string) (time.Duration, error)
 }
 
 // NewTextToSpeechService は新しいGoogle Cloud Text-to-Speechサービスを作成します
 func NewTextToSpeechService() (*TextToSpeechService, error) {
-	ctx := context.Background()
-	client, err := texttospeech.NewClient(ctx)
+	client, err := texttospeech.NewClient(context.Background())
 	if err != nil {
 		// 認証エラーに関するより詳細なメッセージを提供
 		if os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") == "" {
@@ -32,52 +69,83 @@ func NewTextToSpeechService() (*TextToSpeechService, error) {
 		}
 		return nil, fmt.Errorf("google Cloud Text-to-Speechクライアントの作成に失敗しました: %v", err)
 	}
+	audioProcessor := audio.NewAudioProcessor()
 	return &TextToSpeechService{
 		client:         client,
-		ctx:            ctx,
-		audioProcessor: audio.NewAudioProcessor(),
+		audioProcessor: audioProcessor,
+		audioDuration:  audioProcessor.GetAudioDuration,
 	}, nil
 }
 
 // SynthesizeSpeech はGoogle Cloud Text-to-Speech APIを使用してテキストを音声に変換します
-func (s *TextToSpeechService) SynthesizeSpeech(request tts.TextToSpeechRequest) ([]byte, error) {
+func (s *TextToSpeechService) SynthesizeSpeech(ctx context.Context, request tts.TextToSpeechRequest) ([]byte, error) {
 	// ドメインモデルをGoogle Cloud APIリクエストにマッピング
 	req := &texttospeechpb.SynthesizeSpeechRequest{
-		Input: &texttospeechpb.SynthesisInput{
-			InputSource: &texttospeechpb.SynthesisInput_Text{
-				Text: request.Input.Text,
-			},
-		},
+		Input: mapInput(request.Input),
 		Voice: &texttospeechpb.VoiceSelectionParams{
 			LanguageCode: request.Voice.LanguageCode,
 			SsmlGender:   mapGender(request.Voice.Gender),
+			Name:         request.Voice.Name,
 		},
 		AudioConfig: &texttospeechpb.AudioConfig{
-			AudioEncoding: mapAudioFormat(request.AudioConfig.AudioFormat),
+			AudioEncoding:    mapAudioFormat(request.AudioConfig.AudioFormat),
+			SpeakingRate:     request.AudioConfig.SpeakingRate,
+			Pitch:            request.AudioConfig.Pitch,
+			VolumeGainDb:     request.AudioConfig.VolumeGainDb,
+			SampleRateHertz:  int32(request.AudioConfig.SampleRateHertz),
+			EffectsProfileId: request.AudioConfig.EffectsProfileIDs,
 		},
 	}
 
-	resp, err := s.client.SynthesizeSpeech(s.ctx, req)
+	// 再試行はsynthesizeToFileで行うため、クライアントライブラリの再試行は無効にする
+	resp, err := s.client.SynthesizeSpeech(ctx, req, gax.WithRetry(nil))
 	if err != nil {
-		return nil, fmt.Errorf("音声合成に失敗しました: %v", err)
+		return nil, fmt.Errorf("音声合成に失敗しました: %w", classifyError(err))
 	}
 	return resp.AudioContent, nil
 }
 
-// SynthesizeMultiple は複数のテキストをタイミング情報付きで音声に変換します
-func (s *TextToSpeechService) SynthesizeMultiple(requests []tts.TextToSpeechRequest, output io.Writer) error {
+// SynthesizeMultiple は複数のテキストをタイミング情報付きで音声に変換し、タイミングの調整内容を返します
+// 取り消された場合も一時ディレクトリは削除します
+func (s *TextToSpeechService) SynthesizeMultiple(ctx context.Context, requests []tts.TextToSpeechRequest, output io.Writer, options tts.SynthesisOptions) (*tts.SynthesisReport, error) {
 	tempDir, err := s.createTempDir()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer s.cleanupTempDir(tempDir)
 
-	audioFiles, err := s.processRequests(requests, tempDir)
+	clips, fits, err := s.processRequests(ctx, requests, tempDir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.mixAudioFilesWithTiming(ctx, clips, requests, output, options)
+	if err != nil {
+		return nil, err
+	}
+	report.Fits = fits
+	return report, nil
+}
+
+// ListVoices はGoogle Cloud Text-to-Speech APIで使用できる声の一覧を返します
+func (s *TextToSpeechService) ListVoices(ctx context.Context, languageCode string) ([]tts.Voice, error) {
+	resp, err := s.client.ListVoices(ctx, &texttospeechpb.ListVoicesRequest{
+		LanguageCode: languageCode,
+	})
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("声の一覧の取得に失敗しました: %v", err)
 	}
 
-	return s.mixAudioFilesWithTiming(audioFiles, requests, output)
+	voices := make([]tts.Voice, 0, len(resp.Voices))
+	for _, voice := range resp.Voices {
+		voices = append(voices, tts.Voice{
+			Name:                   voice.Name,
+			LanguageCodes:          voice.LanguageCodes,
+			Gender:                 mapVoiceGender(voice.SsmlGender),
+			NaturalSampleRateHertz: int(voice.NaturalSampleRateHertz),
+		})
+	}
+	return voices, nil
 }
 
 // createTempDir は一時ディレクトリを作成します
@@ -90,43 +158,228 @@ func (s *TextToSpeechService) cleanupTempDir(tempDir string) {
 	s.audioProcessor.CleanupTempDir(tempDir)
 }
 
-// processRequests は各テキスト音声変換リクエストを処理します
-func (s *TextToSpeechService) processRequests(requests []tts.TextToSpeechRequest, tempDir string) ([]string, error) {
-	audioFiles := make([]string, len(requests))
+// processRequests は各テキスト音声変換リクエストを並行して処理します
+// 同時に処理する数と1秒あたりのリクエスト数を制限し、最初にエラーが発生した時点で残りの処理を取り消します
+// 合成した音声の長さも並行して取得し、リクエストの順序で開始時間と組み合わせたクリップを返します
+// 表示時間に合わせる調整が指定されている場合は、調整した再生速度をクリップに反映し、調整の結果も返します
+func (s *TextToSpeechService) processRequests(ctx context.Context, requests []tts.TextToSpeechRequest, tempDir string, options tts.SynthesisOptions) ([]audio.Clip, []tts.FitResult, error) {
+	clips := make([]audio.Clip, len(requests))
+	results := make([]*tts.FitResult, len(requests))
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	requestsPerSecond := options.RequestsPerSecond
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = defaultRequestsPerSecond
+	}
+	session := &synthesisSession{
+		limiter: rate.NewLimiter(rate.Limit(requestsPerSecond), 1),
+		retry:   options.Retry,
+	}
 
+	group, ctx := errgroup.WithContext(ctx)
+	group.SetLimit(workers)
 	for i, req := range requests {
-		// 音声を合成
-		audioContent, err := s.SynthesizeSpeech(req)
-		if err != nil {
-			return nil, err
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			// 音声を合成して一時ファイルに保存
+			audioFile := filepath.Join(tempDir, audioFileName(i, req.CueID))
+			if err := s.synthesizeToFile(ctx, session, i, req, audioFile); err != nil {
+				return err
+			}
+			duration, err := s.audioDuration(ctx, audioFile)
+			if err != nil {
+				return fmt.Errorf("字幕 %s の音声の長さの取得に失敗しました: %v", tts.CueLabel(req.CueIndex(i), req.CueID), err)
+			}
+			clip := audio.Clip{File: audioFile, Start: req.StartTime, Duration: duration}
+
+			// 音声を字幕の表示時間に合わせる
+			if options.Fit.Mode != tts.FitNone && req.Slot() > 0 {
+				if clip, results[i], err = s.fitToSlot(ctx, session, i, req, clip, options.Fit); err != nil {
+					return err
+				}
+			}
+			clips[i] = clip
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	fits := []tts.FitResult{}
+	for _, result := range results {
+		if result != nil {
+			fits = append(fits, *result)
 		}
+	}
+	return clips, fits, nil
+}
 
-		// 音声コンテンツを一時ファイルに保存
-		audioFile := filepath.Join(tempDir, fmt.Sprintf("audio_%d.mp3", i))
-		if err := os.WriteFile(audioFile, audioContent, 0644); err != nil {
-			return nil, fmt.Errorf("音声ファイルの書き込みに失敗しました: %v", err)
+// synthesizeToFile はリクエストの音声を合成してファイルに書き込みます
+// APIを呼び出す前にリクエスト数の制限に従って待機し、一時的なエラーの場合は待機時間を延ばしながら再試行します
+func (s *TextToSpeechService) synthesizeToFile(ctx context.Context, session *synthesisSession, index int, req tts.TextToSpeechRequest, audioFile string) error {
+	label := tts.CueLabel(req.CueIndex(index), req.CueID)
+	attempts := session.retry.Attempts()
+
+	var audioContent []byte
+	for attempt := 1; ; attempt++ {
+		if err := session.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("字幕 %s: %w", label, err)
 		}
 
-		audioFiles[i] = audioFile
+		requestCtx, cancel := context.WithTimeout(ctx, session.retry.RequestTimeout())
+		content, err := s.SynthesizeSpeech(requestCtx, req)
+		cancel()
+		if err == nil {
+			audioContent = content
+			break
+		}
+		if attempt >= attempts || !tts.IsRetryable(err) || ctx.Err() != nil {
+			return fmt.Errorf("字幕 %s: %w", label, err)
+		}
+
+		backoff := session.retry.Backoff(attempt)
+		if session.retry.OnRetry != nil {
+			session.retry.OnRetry(tts.RetryEvent{
+				Index:       req.CueIndex(index),
+				CueID:       req.CueID,
+				Attempt:     attempt + 1,
+				MaxAttempts: attempts,
+				Backoff:     backoff,
+				Err:         err,
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("字幕 %s: %w", label, ctx.Err())
+		case <-time.After(backoff):
+		}
 	}
 
-	return audioFiles, nil
+	if err := os.WriteFile(audioFile, audioContent, 0644); err != nil {
+		return fmt.Errorf("字幕 %s の音声ファイルの書き込みに失敗しました: %v", label, err)
+	}
+	return nil
 }
 
-// mixAudioFilesWithTiming はffmpegを使用して全ての音声ファイルを正確なタイミングで結合します
-func (s *TextToSpeechService) mixAudioFilesWithTiming(audioFiles []string, requests []tts.TextToSpeechRequest, output io.Writer) error {
-	if len(audioFiles) == 0 {
-		return fmt.Errorf("結合する音声ファイルがありません")
+// fitToSlot は合成した音声のクリップの長さを字幕の表示時間と比較し、収まらない場合は速さを調整します
+// FitRateの場合は話す速さを上げて合成し直した長さを、FitStretchの場合は結合時に適用する再生速度をクリップに反映します
+// 調整が不要な場合の結果はnilです
+func (s *TextToSpeechService) fitToSlot(ctx context.Context, session *synthesisSession, index int, req tts.TextToSpeechRequest, clip audio.Clip, fit tts.FitOptions) (audio.Clip, *tts.FitResult, error) {
+	slot := req.Slot()
+	duration := clip.Duration
+	if fit.Fits(duration, slot) {
+		return clip, nil, nil
 	}
 
-	// リクエストから開始時間を抽出
-	startTimes := make([]time.Duration, len(requests))
-	for i, req := range requests {
-		startTimes[i] = req.StartTime
+	result := &tts.FitResult{
+		Index:            req.CueIndex(index),
+		CueID:            req.CueID,
+		Mode:             fit.Mode,
+		Slot:             slot,
+		OriginalDuration: duration,
+	}
+
+	switch fit.Mode {
+	case tts.FitRate:
+		// 話す速さと音声の長さは厳密には比例しないため、長さを測り直しながら数回調整する
+		base := req.AudioConfig.SpeakingRate
+		if base == 0 {
+			base = 1
+		}
+		limit := min(base*fit.SpeedupLimit(), tts.MaxSpeakingRate)
+		speakingRate := base
+		for attempt := 0; attempt < maxFitAttempts && !fit.Fits(duration, slot) && speakingRate < limit; attempt++ {
+			speakingRate = min(speakingRate*float64(duration)/float64(slot), limit)
+			req.AudioConfig.SpeakingRate = speakingRate
+			if err := s.synthesizeToFile(ctx, session, index, req, clip.File); err != nil {
+				return clip, nil, err
+			}
+			var err error
+			if duration, err = s.audioDuration(ctx, clip.File); err != nil {
+				return clip, nil, fmt.Errorf("字幕 %s の音声の長さの取得に失敗しました: %v", tts.CueLabel(req.CueIndex(index), req.CueID), err)
+			}
+		}
+		clip.Duration = duration
+		result.Speedup = speakingRate / base
+
+	case tts.FitStretch:
+		result.Speedup = fit.Speedup(duration, slot)
+		clip = clip.WithTempo(result.Speedup)
+	}
+
+	result.Duration = clip.Duration
+	result.Fits = fit.Fits(result.Duration, slot)
+	return clip, result, nil
+}
+
+// mixAudioFilesWithTiming はffmpegを使用して全ての音声クリップを正確なタイミングで結合します
+// 音声が次の字幕と重なる場合は指定された扱いでタイミングを調整します
+func (s *TextToSpeechService) mixAudioFilesWithTiming(ctx context.Context, clips []audio.Clip, requests []tts.TextToSpeechRequest, output io.Writer, options tts.SynthesisOptions) (*tts.SynthesisReport, error) {
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("結合する音声ファイルがありません")
+	}
+
+	// 重なりを調整し、調整内容にキュー識別子を記録
+	clips, adjustments := audio.ResolveOverlaps(clips, options.Overlap)
+	for i := range adjustments {
+		adjustments[i].CueID = requests[adjustments[i].Index].CueID
 	}
 
 	// オーディオプロセッサを使用して音声ファイルを結合
-	return s.audioProcessor.MixAudioFilesWithTiming(audioFiles, startTimes, output)
+	if err := s.audioProcessor.MixClips(ctx, clips, output); err != nil {
+		return nil, err
+	}
+
+	return &tts.SynthesisReport{Adjustments: adjustments}, nil
+}
+
+// audioFileName はリクエストの番号とキュー識別子から一時音声ファイル名を作成します
+func audioFileName(index int, cueID string) string {
+	id := unsafeFileNameRegex.ReplaceAllString(cueID, "_")
+	if len(id) > maxFileNameIDLength {
+		id = id[:maxFileNameIDLength]
+	}
+	if id == "" {
+		return fmt.Sprintf("audio_%d.mp3", index)
+	}
+	return fmt.Sprintf("audio_%d_%s.mp3", index, id)
+}
+
+// classifyError はAPIのエラーをgRPCのステータスコードで分類します
+// サーバーの一時的な停止、割り当ての超過、制限時間の超過は再試行できるエラーとして扱います
+func classifyError(err error) error {
+	code := status.Code(err)
+	if code == codes.Unknown && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+		code = status.FromContextError(err).Code()
+	}
+	return &tts.SynthesisError{
+		Code:      code.String(),
+		Retryable: code == codes.Unavailable || code == codes.ResourceExhausted || code == codes.DeadlineExceeded,
+		Err:       err,
+	}
+}
+
+// mapInput はドメインの入力をGoogle Cloud APIの入力にマッピングします
+// SSMLが指定されている場合はテキストの代わりにSSMLを送信します
+func mapInput(input tts.SynthesisInput) *texttospeechpb.SynthesisInput {
+	if input.SSML != "" {
+		return &texttospeechpb.SynthesisInput{
+			InputSource: &texttospeechpb.SynthesisInput_Ssml{
+				Ssml: input.SSML,
+			},
+		}
+	}
+	return &texttospeechpb.SynthesisInput{
+		InputSource: &texttospeechpb.SynthesisInput_Text{
+			Text: input.Text,
+		},
+	}
 }
 
 // mapGender はドメインの性別をGoogle Cloud APIの性別にマッピングします
@@ -143,6 +396,18 @@ func mapGender(gender tts.VoiceGender) texttospeechpb.SsmlVoiceGender {
 	}
 }
 
+// mapVoiceGender はGoogle Cloud APIの性別をドメインの性別にマッピングします
+func mapVoiceGender(gender texttospeechpb.SsmlVoiceGender) tts.VoiceGender {
+	switch gender {
+	case texttospeechpb.SsmlVoiceGender_MALE:
+		return tts.Male
+	case texttospeechpb.SsmlVoiceGender_FEMALE:
+		return tts.Female
+	default:
+		return tts.Neutral
+	}
+}
+
 // mapAudioFormat はドメインの音声フォーマットをGoogle Cloud APIの音声フォーマットにマッピングします
 func mapAudioFormat(format tts.AudioFormat) texttospeechpb.AudioEncoding {
 	switch format {
@@ -0,0 +1,170 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+	"vtt2mp3/domain/audio"
+	"vtt2mp3/domain/tts"
+
+	"cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"github.com/googleapis/gax-go/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeSpeechClient はテキストを音声の内容として返すテスト用のクライアントです
+type fakeSpeechClient struct {
+	calls      atomic.Int32
+	synthesize func(ctx context.Context, text string) error
+}
+
+func (c *fakeSpeechClient) SynthesizeSpeech(ctx context.Context, req *texttospeechpb.SynthesizeSpeechRequest, opts ...gax.CallOption) (*texttospeechpb.SynthesizeSpeechResponse, error) {
+	c.calls.Add(1)
+	text := req.Input.GetText()
+	if c.synthesize != nil {
+		if err := c.synthesize(ctx, text); err != nil {
+			return nil, err
+		}
+	}
+	return &texttospeechpb.SynthesizeSpeechResponse{AudioContent: []byte(text)}, nil
+}
+
+func (c *fakeSpeechClient) ListVoices(ctx context.Context, req *texttospeechpb.ListVoicesRequest, opts ...gax.CallOption) (*texttospeechpb.ListVoicesResponse, error) {
+	return &texttospeechpb.ListVoicesResponse{}, nil
+}
+
+// newFakeService はテスト用のクライアントを使用するサービスを作成します
+// 音声ファイルの長さは内容の1バイトを100ミリ秒として数えます
+func newFakeService(client *fakeSpeechClient, probes *atomic.Int32) *TextToSpeechService {
+	return &TextToSpeechService{
+		client:         client,
+		audioProcessor: audio.NewAudioProcessor(),
+		audioDuration: func(ctx context.Context, audioFile string) (time.Duration, error) {
+			probes.Add(1)
+			content, err := os.ReadFile(audioFile)
+			if err != nil {
+				return 0, err
+			}
+			return time.Duration(len(content)) * 100 * time.Millisecond, nil
+		},
+	}
+}
+
+func TestProcessRequestsKeepsOrder(t *testing.T) {
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	client := &fakeSpeechClient{
+		// 後のリクエストほど先に完了させる
+		synthesize: func(ctx context.Context, text string) error {
+			time.Sleep(time.Duration(len(texts)-len(text)) * 5 * time.Millisecond)
+			return nil
+		},
+	}
+	var probes atomic.Int32
+	service := newFakeService(client, &probes)
+
+	requests := make([]tts.TextToSpeechRequest, len(texts))
+	for i, text := range texts {
+		requests[i] = tts.TextToSpeechRequest{Input: tts.SynthesisInput{Text: text}, StartTime: time.Duration(i) * time.Second}
+	}
+	// 最後の字幕だけ表示時間（250ミリ秒）に収まらない
+	requests[4].EndTime = requests[4].StartTime + 250*time.Millisecond
+
+	options := tts.SynthesisOptions{Workers: len(texts), RequestsPerSecond: 1000, Fit: tts.FitOptions{Mode: tts.FitStretch}}
+	clips, fits, err := service.processRequests(context.Background(), requests, t.TempDir(), options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(clips) != len(texts) {
+		t.Fatalf("got %d clips, want %d", len(clips), len(texts))
+	}
+	for i, clip := range clips {
+		content, err := os.ReadFile(clip.File)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != texts[i] || clip.Start != requests[i].StartTime {
+			t.Errorf("clip %d = {%q %v}, want {%q %v}", i, content, clip.Start, texts[i], requests[i].StartTime)
+		}
+	}
+
+	if clips[4].Tempo != 1.5 || clips[4].Duration >= 500*time.Millisecond {
+		t.Errorf("fitted clip = %+v, want tempo 1.5", clips[4])
+	}
+	if len(fits) != 1 || fits[0].Index != 4 || fits[0].OriginalDuration != 500*time.Millisecond || fits[0].Duration != clips[4].Duration {
+		t.Errorf("fits = %+v, want one result for clip 4", fits)
+	}
+	// 長さは音声ごとに1回だけ取得する
+	if got := probes.Load(); got != int32(len(texts)) {
+		t.Errorf("probed %d times, want %d", got, len(texts))
+	}
+}
+
+func TestProcessRequestsCancelsOnFirstError(t *testing.T) {
+	client := &fakeSpeechClient{
+		synthesize: func(ctx context.Context, text string) error {
+			if text == "fail" {
+				return status.Error(codes.InvalidArgument, "bad voice")
+			}
+			// 取り消されるまで完了しない
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	var probes atomic.Int32
+	service := newFakeService(client, &probes)
+
+	requests := make([]tts.TextToSpeechRequest, 10)
+	for i := range requests {
+		requests[i] = tts.TextToSpeechRequest{Input: tts.SynthesisInput{Text: fmt.Sprintf("text %d", i)}}
+	}
+	requests[0].Input.Text = "fail"
+
+	options := tts.SynthesisOptions{Workers: 2, RequestsPerSecond: 1000}
+	_, _, err := service.processRequests(context.Background(), requests, t.TempDir(), options)
+
+	var synthesisErr *tts.SynthesisError
+	if !errors.As(err, &synthesisErr) || synthesisErr.Code != "InvalidArgument" {
+		t.Fatalf("processRequests() error = %v, want the InvalidArgument error", err)
+	}
+	if calls := client.calls.Load(); calls >= int32(len(requests)) {
+		t.Errorf("synthesized %d requests, want the remaining requests to be cancelled", calls)
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantCode      string
+		wantRetryable bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "down"), "Unavailable", true},
+		{"quota", status.Error(codes.ResourceExhausted, "quota"), "ResourceExhausted", true},
+		{"deadline", status.Error(codes.DeadlineExceeded, "slow"), "DeadlineExceeded", true},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad voice"), "InvalidArgument", false},
+		{"context deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), "DeadlineExceeded", true},
+		{"context canceled", context.Canceled, "Canceled", false},
+		{"unknown", errors.New("boom"), "Unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var synthesisErr *tts.SynthesisError
+			if !errors.As(classifyError(tt.err), &synthesisErr) {
+				t.Fatal("expected *tts.SynthesisError")
+			}
+			if synthesisErr.Code != tt.wantCode || synthesisErr.Retryable != tt.wantRetryable {
+				t.Errorf("got code %s retryable %v, want %s %v", synthesisErr.Code, synthesisErr.Retryable, tt.wantCode, tt.wantRetryable)
+			}
+			if !errors.Is(synthesisErr, tt.err) {
+				t.Error("original error is not wrapped")
+			}
+		})
+	}
+}
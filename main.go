@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"vtt2mp3/application"
 	"vtt2mp3/infrastructure/google"
@@ -11,9 +14,7 @@ import (
 
 // Config はアプリケーションの設定を保持する構造体
 type Config struct {
-	TTSService     *google.TextToSpeechService
-	VTT2MP3Service *application.VTT2MP3Service
-	CLI            *presentation.CLI
+	CLI *presentation.CLI
 }
 
 // vtt2mp3 はGoogle Cloud Text-to-Speech APIを使用してVTTファイルをMP3またはMP4ファイルに変換するコマンドラインツールです。
@@ -21,6 +22,7 @@ type Config struct {
 //
 //	vtt2mp3 -i input.vtt -o output.mp3 -l ja
 //	vtt2mp3 -i input.vtt -o output.mp4 -l ja
+//	vtt2mp3 validate input.vtt
 //
 // フラグ:
 //
@@ -30,31 +32,33 @@ type Config struct {
 //
 // 出力ファイルの拡張子が.mp4の場合、黒い背景と字幕を含む動画が生成されます。
 func main() {
-	config, err := initializeApp()
+	config := initializeApp()
+
+	// Ctrl-Cまたは終了シグナルで実行中の処理を中断し、一時ファイルを削除してから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := config.CLI.Run(ctx, os.Args[1:])
+	stop()
 	if err != nil {
 		handleFatalError(err)
 	}
+}
 
-	if err := config.CLI.Run(os.Args[1:]); err != nil {
-		handleFatalError(err)
+// initializeApp はアプリケーションの依存性を初期化する
+// テキスト読み上げサービスは音声合成が必要なサブコマンドの実行時に初期化する
+func initializeApp() *Config {
+	return &Config{
+		CLI: presentation.NewCLI(newVTT2MP3Service),
 	}
 }
 
-// initializeApp はアプリケーションの依存性を初期化する
-func initializeApp() (*Config, error) {
+// newVTT2MP3Service はGoogle Cloud Text-to-Speechを使用する変換サービスを作成する
+func newVTT2MP3Service() (*application.VTT2MP3Service, error) {
 	ttsService, err := google.NewTextToSpeechService()
 	if err != nil {
 		return nil, fmt.Errorf("テキスト読み上げサービスの初期化に失敗: %v", err)
 	}
 
-	vtt2mp3Service := application.NewVTT2MP3Service(ttsService)
-	cli := presentation.NewCLI(vtt2mp3Service)
-
-	return &Config{
-		TTSService:     ttsService,
-		VTT2MP3Service: vtt2mp3Service,
-		CLI:            cli,
-	}, nil
+	return application.NewVTT2MP3Service(ttsService), nil
 }
 
 // handleFatalError はエラーを標準エラー出力に表示してプログラムを終了する
@@ -1,62 +1,421 @@
 package presentation
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"vtt2mp3/application"
+	"vtt2mp3/domain/audio"
+	"vtt2mp3/domain/tts"
+	"vtt2mp3/domain/vtt"
 )
 
+// stdioPath は標準入力または標準出力を表すファイル名です
+const stdioPath = "-"
+
+// stringListFlag は複数回指定できる文字列フラグを表します
+type stringListFlag []string
+
+// String は指定された値をカンマ区切りで返します
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set は値を追加します
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// ServiceFactory は変換サービスを作成する関数を表します
+// 音声合成サービスを使用しないサブコマンドでは呼び出されません
+type ServiceFactory func() (*application.VTT2MP3Service, error)
+
 // CLI はアプリケーションのコマンドラインインターフェースを表します
 type CLI struct {
-	service *application.VTT2MP3Service
+	newService ServiceFactory
 }
 
 // NewCLI は新しいCLIを作成します
-func NewCLI(service *application.VTT2MP3Service) *CLI {
+func NewCLI(newService ServiceFactory) *CLI {
 	return &CLI{
-		service: service,
+		newService: newService,
 	}
 }
 
 // Run はCLIアプリケーションを実行します
-func (c *CLI) Run(args []string) error {
+// 最初の引数がサブコマンド名の場合はそのサブコマンドを、それ以外は変換を実行します
+// コンテキストが取り消された場合（Ctrl-Cなど）は実行中の処理を中断します
+func (c *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			return c.runValidate(args[1:])
+		case "voices":
+			return c.runVoices(ctx, args[1:])
+		}
+	}
+	return c.runConvert(ctx, args)
+}
+
+// runConvert は字幕ファイルを音声または動画に変換します
+func (c *CLI) runConvert(ctx context.Context, args []string) error {
 	// コマンドラインフラグを定義
 	flagSet := flag.NewFlagSet("vtt2mp3", flag.ExitOnError)
-	inputFile := flagSet.String("i", "input.vtt", "入力VTTファイル")
-	outputFile := flagSet.String("o", "out.mp3", "出力MP3ファイル")
+	inputFile := flagSet.String("i", "input.vtt", "入力字幕ファイル（VTT, SRT, TTML, ASS, JSON, SBV）。-で標準入力")
+	outputFile := flagSet.String("o", "out.mp3", "出力MP3ファイル。-で標準出力")
+	inputFormatName := flagSet.String("f", "auto", "入力形式（auto, vtt, srt, ttml, ass, json, sbv）")
+	charset := flagSet.String("charset", vtt.CharsetAuto, "入力の文字コード（auto, utf-8, shift_jis, euc-kr, gb18030 など）")
+	forceVideo := flagSet.Bool("video", false, "出力ファイルの拡張子に関わらずMP4動画を出力する")
 	languageCode := flagSet.String("l", "ja", "言語コード")
+	voiceName := flagSet.String("voice", "", "使用する声の名前（例: ja-JP-Neural2-B、一覧は vtt2mp3 voices で確認）")
+	speakerMapFile := flagSet.String("speaker-map", "", "話者と声の対応を記述したJSONファイル")
+	offset := flagSet.Duration("offset", 0, "すべての字幕のタイミングをずらす時間（例: -10s）")
+	scale := flagSet.Float64("scale", 1, "すべての字幕のタイミングに掛ける倍率")
+	frameRate := flagSet.String("framerate", "", "フレームレートの変換（変換元:変換先、例: 23.976:25）")
+	trimStart := flagSet.Duration("trim-start", 0, "切り出す区間の開始時間（例: 1m30s）")
+	trimEnd := flagSet.Duration("trim-end", 0, "切り出す区間の終了時間（0は最後まで）")
+	overlapPolicyName := flagSet.String("overlap", "allow", "音声が次の字幕と重なる場合の扱い（allow, push, speedup, truncate）")
+	maxSpeedup := flagSet.Float64("max-speedup", 2.0, "-overlap speedupで許容する最大の再生速度")
+	fadeDuration := flagSet.Duration("fade", 100*time.Millisecond, "-overlap truncateで打ち切る際のフェードアウトの長さ")
+	fitModeName := flagSet.String("fit", "none", "音声が字幕の表示時間に収まらない場合の調整（none, rate, stretch）")
+	fitMaxSpeedup := flagSet.Float64("fit-max-speedup", 1.5, "-fitで許容する最大の速さの倍率")
+	workers := flagSet.Int("workers", 0, "並行して音声を合成する数（0はプロバイダーの既定値）")
+	requestsPerSecond := flagSet.Float64("rps", 0, "1秒あたりの音声合成リクエスト数の上限（0はプロバイダーの既定値）")
+	maxAttempts := flagSet.Int("max-attempts", 5, "一時的なエラーで音声合成に失敗した場合の最大の試行回数（1は再試行しない）")
+	requestTimeout := flagSet.Duration("request-timeout", 30*time.Second, "音声合成の1回のリクエストの制限時間")
+	reportFile := flagSet.String("report", "", "タイミングの調整内容を書き込むJSONファイル")
+	normalize := flagSet.Bool("normalize", true, "読み上げる前に効果音の説明、話者名、URLなどを取り除く")
+	normalizeRulesFile := flagSet.String("normalize-rules", "", "読み上げ用のテキストの整形ルールを記述したJSONファイル")
+	segment := flagSet.Bool("segment", false, "音声合成の前に字幕を文単位に結合・分割する（字幕の表示は元のまま）")
+	segmentMaxLength := flagSet.Int("segment-max-chars", 0, "-segmentで結合・分割した字幕の最大文字数（0は言語ごとの既定値）")
+	segmentMaxGap := flagSet.Duration("segment-max-gap", 0, "-segmentで結合する字幕の間の最大の間隔（例: 1s、0は既定値）")
+	speakingRate := flagSet.Float64("rate", 0, "話す速さ（0.25〜4.0、0はプロバイダーの既定値）")
+	pitch := flagSet.Float64("pitch", 0, "声の高さ（-20〜20の半音単位）")
+	volumeGain := flagSet.Float64("volume", 0, "音量の増減（-96〜16dB）")
+	sampleRate := flagSet.Int("sample-rate", 0, "合成する音声のサンプルレート（Hz、0は声の本来のサンプルレート）")
+	autoSSML := flagSet.Bool("ssml", false, "字幕のテキストから自動でSSMLを生成する（省略記号の間、日付・数値の読み方、強調）")
+	cueProsodyFile := flagSet.String("cue-prosody", "", "キュー識別子ごとの音声の設定を記述したJSONファイル（識別子のない字幕には適用されない）")
+	var speakerFlags, voicePoolFlags, lexiconFlags, effectsProfileFlags stringListFlag
+	flagSet.Var(&effectsProfileFlags, "effects-profile", "再生機器に合わせた効果（例: headphone-class-device）。複数指定可")
+	flagSet.Var(&lexiconFlags, "lexicon", "発音辞書のJSONファイル。複数指定可（後に指定した辞書を優先）")
+	flagSet.Var(&speakerFlags, "speaker", "話者の声の指定（例: \"Alice=ja-JP-Neural2-B,rate:1.1\"）。複数指定可")
+	flagSet.Var(&voicePoolFlags, "voice-pool", "マッピングのない話者に割り当てる声（例: \"ja-JP-Neural2-C,pitch:-2\"）。複数指定可")
 
 	// コマンドラインフラグを解析
 	if err := flagSet.Parse(args); err != nil {
 		return fmt.Errorf("コマンドラインフラグの解析に失敗しました: %v", err)
 	}
 
+	// 標準出力に変換結果を書き込む場合、メッセージは標準エラー出力に表示する
+	var messages io.Writer = os.Stdout
+	if *outputFile == stdioPath {
+		messages = os.Stderr
+	}
+
+	inputFormat, err := vtt.ParseFormat(*inputFormatName)
+	if err != nil {
+		return err
+	}
+
+	overlapPolicy, err := audio.ParseOverlapPolicy(*overlapPolicyName)
+	if err != nil {
+		return err
+	}
+
+	fitMode, err := tts.ParseFitMode(*fitModeName)
+	if err != nil {
+		return err
+	}
+	if *workers < 0 || *requestsPerSecond < 0 {
+		return fmt.Errorf("-workersと-rpsは0以上で指定してください")
+	}
+	if *maxAttempts < 1 || *requestTimeout <= 0 {
+		return fmt.Errorf("-max-attemptsは1以上、-request-timeoutは正の値で指定してください")
+	}
+	if *maxSpeedup < 1 {
+		return fmt.Errorf("-max-speedupは1以上で指定してください: %v", *maxSpeedup)
+	}
+	if *fitMaxSpeedup < 1 {
+		return fmt.Errorf("-fit-max-speedupは1以上で指定してください: %v", *fitMaxSpeedup)
+	}
+
 	// オプションを表示
-	fmt.Printf("%sを%sに言語%sで変換しています\n", *inputFile, *outputFile, *languageCode)
+	fmt.Fprintf(messages, "%sを%sに言語%sで変換しています\n", *inputFile, *outputFile, *languageCode)
 
 	// 出力ファイルがMP4（動画出力）かどうかを確認
-	isVideoOutput := false
+	isVideoOutput := *forceVideo
 	if filepath.Ext(*outputFile) == ".mp4" {
 		isVideoOutput = true
-		fmt.Println(".mp4拡張子を検出しました、動画出力を生成します")
+		fmt.Fprintln(messages, ".mp4拡張子を検出しました、動画出力を生成します")
+	}
+
+	// 変換サービスを作成
+	service, err := c.newService()
+	if err != nil {
+		return err
+	}
+
+	// 話者と声の対応を作成
+	speakerVoices, err := buildSpeakerVoices(*speakerMapFile, speakerFlags, voicePoolFlags)
+	if err != nil {
+		return err
+	}
+
+	// タイミングの変換を作成
+	timeline := vtt.TimelineOptions{
+		Scale:     *scale,
+		Offset:    *offset,
+		TrimStart: *trimStart,
+		TrimEnd:   *trimEnd,
+	}
+	if *frameRate != "" {
+		timeline.SourceFrameRate, timeline.TargetFrameRate, err = parseFrameRateConversion(*frameRate)
+		if err != nil {
+			return err
+		}
+	}
+
+	// 読み上げ用のテキストの整形処理を作成
+	var normalizer tts.Normalizer
+	switch {
+	case *normalizeRulesFile != "" && !*normalize:
+		return fmt.Errorf("-normalize=falseと-normalize-rulesは同時に指定できません（組み込みの処理を無効にする場合はルールファイルで\"defaults\": falseを指定してください）")
+	case *normalizeRulesFile != "":
+		normalizer, err = tts.LoadNormalizers(*normalizeRulesFile, *languageCode)
+		if err != nil {
+			return fmt.Errorf("整形ルールファイルの読み込みに失敗しました: %v", err)
+		}
+	case !*normalize:
+		normalizer = tts.NormalizerChain{}
+	}
+
+	// 音声の設定を作成
+	prosody := tts.Prosody{
+		SpeakingRate:      *speakingRate,
+		Pitch:             *pitch,
+		VolumeGainDb:      *volumeGain,
+		SampleRateHertz:   *sampleRate,
+		EffectsProfileIDs: effectsProfileFlags,
+	}
+	if err := prosody.Validate(); err != nil {
+		return err
+	}
+	var cueProsody tts.CueProsody
+	if *cueProsodyFile != "" {
+		cueProsody, err = tts.LoadCueProsody(*cueProsodyFile)
+		if err != nil {
+			return fmt.Errorf("キューごとの音声設定ファイルの読み込みに失敗しました: %v", err)
+		}
+	}
+
+	// 発音辞書を読み込む
+	lexicon, err := loadLexicons(lexiconFlags)
+	if err != nil {
+		return err
+	}
+
+	// 字幕の再分割の設定を作成
+	var segmentation *vtt.SegmentOptions
+	if *segment {
+		segmentation = &vtt.SegmentOptions{
+			LanguageCode: *languageCode,
+			MaxGap:       *segmentMaxGap,
+			MaxLength:    *segmentMaxLength,
+		}
 	}
 
 	// VTTをMP3またはMP4に変換
 	options := application.ConvertOptions{
 		InputFile:     *inputFile,
 		OutputFile:    *outputFile,
+		InputFormat:   inputFormat,
+		InputCharset:  *charset,
 		LanguageCode:  *languageCode,
+		VoiceName:     *voiceName,
 		IsVideoOutput: isVideoOutput,
+		SpeakerVoices: speakerVoices,
+		Timeline:      timeline,
+		Segmentation:  segmentation,
+		Normalizer:    normalizer,
+		Prosody:       prosody,
+		CueProsody:    cueProsody,
+		Lexicon:       lexicon,
+		AutoSSML:      *autoSSML,
+		Synthesis: tts.SynthesisOptions{
+			Overlap: audio.OverlapOptions{
+				Policy:       overlapPolicy,
+				MaxSpeedup:   *maxSpeedup,
+				FadeDuration: *fadeDuration,
+			},
+			Fit: tts.FitOptions{
+				Mode:       fitMode,
+				MaxSpeedup: *fitMaxSpeedup,
+			},
+			Workers:           *workers,
+			RequestsPerSecond: *requestsPerSecond,
+			Retry: tts.RetryOptions{
+				MaxAttempts: *maxAttempts,
+				Timeout:     *requestTimeout,
+				OnRetry:     reportRetry,
+			},
+		},
 	}
-	if err := c.service.Convert(options); err != nil {
+	report, err := convert(ctx, service, options)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("変換を中断しました: %w", ctx.Err())
+		}
 		if isVideoOutput {
 			return fmt.Errorf("VTTをMP4に変換できませんでした: %v", err)
 		}
 		return fmt.Errorf("VTTをMP3に変換できませんでした: %v", err)
 	}
 
-	fmt.Printf("%sを%sに変換しました\n", *inputFile, *outputFile)
+	fmt.Fprintf(messages, "%sを%sに変換しました\n", *inputFile, *outputFile)
+
+	// タイミングの調整内容を報告
+	if len(report.Adjustments) > 0 {
+		fmt.Fprintf(messages, "%d件の字幕で音声の重なりがありました（%s）\n", len(report.Adjustments), overlapPolicy)
+	}
+	if len(report.Fits) > 0 {
+		fmt.Fprintf(messages, "%d件の字幕で音声を表示時間に合わせました（%s）\n", len(report.Fits), fitMode)
+	}
+	for _, result := range report.Unfit() {
+		fmt.Fprintf(os.Stderr, "警告: 字幕 %s の音声が表示時間に収まりませんでした（%.2f秒 > %.2f秒）\n",
+			tts.CueLabel(result.Index, result.CueID), result.Duration.Seconds(), result.Slot.Seconds())
+	}
+	if *reportFile != "" {
+		if err := writeReport(*reportFile, report); err != nil {
+			return fmt.Errorf("レポートの書き込みに失敗しました: %v", err)
+		}
+	}
 	return nil
 }
+
+// reportRetry は音声合成を再試行することを警告として標準エラー出力に表示します
+func reportRetry(event tts.RetryEvent) {
+	fmt.Fprintf(os.Stderr, "警告: 字幕 %s の音声合成に失敗したため、%v後に再試行します（%d/%d回目）: %v\n",
+		tts.CueLabel(event.Index, event.CueID), event.Backoff.Round(time.Millisecond), event.Attempt, event.MaxAttempts, event.Err)
+}
+
+// writeReport は変換のレポートをJSON形式でファイルに書き込みます
+func writeReport(path string, report *tts.SynthesisReport) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// convert は入力または出力に"-"が指定された場合は標準入出力を使用して変換します
+// 出力がファイルの場合は、変換が成功した場合のみ出力ファイルを置き換えます
+func convert(ctx context.Context, service *application.VTT2MP3Service, options application.ConvertOptions) (report *tts.SynthesisReport, err error) {
+	if options.OutputFile != stdioPath {
+		if options.InputFile == stdioPath {
+			return service.ConvertStreamToFile(ctx, os.Stdin, options)
+		}
+		return service.Convert(ctx, options)
+	}
+
+	var input io.Reader = os.Stdin
+	if options.InputFile != stdioPath {
+		if options.InputFormat == vtt.FormatUnknown {
+			options.InputFormat = vtt.FormatFromExtension(options.InputFile)
+		}
+		file, err := os.Open(options.InputFile)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			closeErr := file.Close()
+			if closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+		input = file
+	}
+
+	return service.ConvertStream(ctx, input, os.Stdout, options)
+}
+
+// parseFrameRateConversion は"変換元:変換先"形式のフレームレートの変換を解析します
+func parseFrameRateConversion(spec string) (float64, float64, error) {
+	sourceSpec, targetSpec, found := strings.Cut(spec, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("フレームレートの変換は\"変換元:変換先\"の形式で指定してください: %s", spec)
+	}
+	source, err := vtt.ParseFrameRate(sourceSpec)
+	if err != nil {
+		return 0, 0, err
+	}
+	target, err := vtt.ParseFrameRate(targetSpec)
+	if err != nil {
+		return 0, 0, err
+	}
+	return source, target, nil
+}
+
+// loadLexicons は複数の発音辞書ファイルを読み込み、1つの辞書にまとめます
+// ファイルが指定されていない場合はnilを返します
+func loadLexicons(paths []string) (*tts.Lexicon, error) {
+	var lexicon *tts.Lexicon
+	for _, path := range paths {
+		loaded, err := tts.LoadLexicon(path)
+		if err != nil {
+			return nil, fmt.Errorf("発音辞書の読み込みに失敗しました: %v", err)
+		}
+		if lexicon == nil {
+			lexicon = loaded
+			continue
+		}
+		lexicon.Merge(loaded)
+	}
+	return lexicon, nil
+}
+
+// buildSpeakerVoices はマッピングファイルとフラグから話者と声の対応を作成します
+// フラグでの指定はマッピングファイルの内容より優先されます
+func buildSpeakerVoices(mapFile string, speakerSpecs, poolSpecs []string) (*tts.SpeakerVoices, error) {
+	pool := make([]tts.VoiceProfile, 0, len(poolSpecs))
+	for _, spec := range poolSpecs {
+		profile, err := tts.ParseVoiceProfile(spec)
+		if err != nil {
+			return nil, err
+		}
+		pool = append(pool, profile)
+	}
+
+	speakerVoices := tts.NewSpeakerVoices(nil, pool)
+	if mapFile != "" {
+		var err error
+		speakerVoices, err = tts.LoadSpeakerVoices(mapFile)
+		if err != nil {
+			return nil, fmt.Errorf("話者マッピングファイルの読み込みに失敗しました: %v", err)
+		}
+		if len(pool) > 0 {
+			speakerVoices.SetPool(pool)
+		}
+	}
+
+	for _, spec := range speakerSpecs {
+		speaker, profileSpec, found := strings.Cut(spec, "=")
+		if !found || strings.TrimSpace(speaker) == "" {
+			return nil, fmt.Errorf("話者の声の指定が不正です（話者名=声の設定）: %s", spec)
+		}
+		profile, err := tts.ParseVoiceProfile(profileSpec)
+		if err != nil {
+			return nil, err
+		}
+		speakerVoices.Set(strings.TrimSpace(speaker), profile)
+	}
+
+	return speakerVoices, nil
+}
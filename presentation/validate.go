@@ -0,0 +1,153 @@
+package presentation
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"vtt2mp3/domain/vtt"
+)
+
+// ErrValidationFailed は検証で問題が見つかった場合のエラーです
+var ErrValidationFailed = errors.New("字幕ファイルの検証で問題が見つかりました")
+
+// validationResult は1つのファイルの検証結果を表します
+type validationResult struct {
+	File        string           `json:"file"`
+	Diagnostics []vtt.Diagnostic `json:"diagnostics"`
+}
+
+// runValidate は字幕ファイルを検証し、見つかった問題を表示します
+// エラーがある場合（-strictの場合は警告がある場合も）はErrValidationFailedを返します
+// -strictの場合はキューの重なりもエラーとして報告します
+func (c *CLI) runValidate(args []string) error {
+	flagSet := flag.NewFlagSet("vtt2mp3 validate", flag.ExitOnError)
+	outputFormat := flagSet.String("format", "text", "出力形式（text または json）")
+	inputFormatName := flagSet.String("f", "auto", "入力形式（auto, vtt, srt, ttml, ass, json, sbv）")
+	charset := flagSet.String("charset", vtt.CharsetAuto, "入力の文字コード（auto, utf-8, shift_jis, euc-kr, gb18030 など）")
+	strict := flagSet.Bool("strict", false, "キューの重なりをエラーとし、警告がある場合も失敗として扱う")
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "使用方法: vtt2mp3 validate [オプション] ファイル...")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("コマンドラインフラグの解析に失敗しました: %v", err)
+	}
+	if flagSet.NArg() == 0 {
+		flagSet.Usage()
+		return fmt.Errorf("検証する字幕ファイルを指定してください")
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		return fmt.Errorf("不明な出力形式です: %s", *outputFormat)
+	}
+
+	inputFormat, err := vtt.ParseFormat(*inputFormatName)
+	if err != nil {
+		return err
+	}
+
+	results := make([]validationResult, 0, flagSet.NArg())
+	for _, file := range flagSet.Args() {
+		diagnostics, err := validateFile(file, inputFormat, *charset, vtt.ValidateOptions{Strict: *strict})
+		if err != nil {
+			return fmt.Errorf("%sの検証に失敗しました: %v", file, err)
+		}
+		results = append(results, validationResult{File: file, Diagnostics: diagnostics})
+	}
+
+	if *outputFormat == "json" {
+		if err := writeValidationJSON(os.Stdout, results); err != nil {
+			return err
+		}
+	} else {
+		writeValidationText(os.Stdout, results)
+	}
+
+	for _, result := range results {
+		if vtt.HasErrors(result.Diagnostics) || (*strict && len(result.Diagnostics) > 0) {
+			return ErrValidationFailed
+		}
+	}
+	return nil
+}
+
+// validateFile は1つの字幕ファイル（"-"の場合は標準入力）を検証します
+func validateFile(file string, format vtt.Format, charset string, options vtt.ValidateOptions) (diagnostics []vtt.Diagnostic, err error) {
+	if file == stdioPath {
+		return validateInput(os.Stdin, format, charset, options)
+	}
+
+	if format == vtt.FormatUnknown {
+		format = vtt.FormatFromExtension(file)
+	}
+
+	input, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := input.Close()
+		if closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	return validateInput(input, format, charset, options)
+}
+
+// validateInput は字幕データをUTF-8に変換してから検証します
+func validateInput(input io.Reader, format vtt.Format, charset string, options vtt.ValidateOptions) ([]vtt.Diagnostic, error) {
+	decoded, err := vtt.NewDecodingReader(input, charset)
+	if err != nil {
+		return nil, err
+	}
+	return vtt.Validate(decoded, format, options)
+}
+
+// writeValidationText は検証結果を"ファイル:行:列: 重大度[コード]: メッセージ"の形式で書き込みます
+// 位置が不明な場合は行と列（WebVTT以外の形式では列）を省略します
+func writeValidationText(w io.Writer, results []validationResult) {
+	for _, result := range results {
+		errorCount, warningCount := 0, 0
+		for _, diagnostic := range result.Diagnostics {
+			location := result.File
+			if diagnostic.Line > 0 {
+				location += fmt.Sprintf(":%d", diagnostic.Line)
+			}
+			if diagnostic.Column > 0 {
+				location += fmt.Sprintf(":%d", diagnostic.Column)
+			}
+			fmt.Fprintf(w, "%s: %s[%s]: %s\n", location, diagnostic.Severity, diagnostic.Code, diagnostic.Message)
+			if diagnostic.Suggestion != "" {
+				fmt.Fprintf(w, "    修正案: %s\n", diagnostic.Suggestion)
+			}
+
+			if diagnostic.Severity == vtt.SeverityError {
+				errorCount++
+			} else {
+				warningCount++
+			}
+		}
+		fmt.Fprintf(w, "%s: エラー %d件、警告 %d件\n", result.File, errorCount, warningCount)
+	}
+}
+
+// writeValidationJSON は検証結果をJSON形式で書き込みます
+func writeValidationJSON(w io.Writer, results []validationResult) error {
+	for i := range results {
+		if results[i].Diagnostics == nil {
+			results[i].Diagnostics = []vtt.Diagnostic{}
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(results); err != nil {
+		return fmt.Errorf("検証結果の書き込みに失敗しました: %v", err)
+	}
+	return nil
+}
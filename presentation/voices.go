@@ -0,0 +1,73 @@
+package presentation
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"vtt2mp3/domain/tts"
+)
+
+// runVoices は音声合成に使用できる声の一覧を表示します
+func (c *CLI) runVoices(ctx context.Context, args []string) error {
+	flagSet := flag.NewFlagSet("vtt2mp3 voices", flag.ExitOnError)
+	languageCode := flagSet.String("l", "", "表示する声の言語コード（例: ja-JP、空の場合はすべての言語）")
+	outputFormat := flagSet.String("format", "text", "出力形式（text または json）")
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "使用方法: vtt2mp3 voices [オプション]")
+		flagSet.PrintDefaults()
+	}
+
+	if err := flagSet.Parse(args); err != nil {
+		return fmt.Errorf("コマンドラインフラグの解析に失敗しました: %v", err)
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		return fmt.Errorf("不明な出力形式です: %s", *outputFormat)
+	}
+
+	service, err := c.newService()
+	if err != nil {
+		return err
+	}
+
+	voices, err := service.ListVoices(ctx, *languageCode)
+	if err != nil {
+		return err
+	}
+
+	if *outputFormat == "json" {
+		return writeVoicesJSON(os.Stdout, voices)
+	}
+	return writeVoicesText(os.Stdout, voices)
+}
+
+// writeVoicesText は声の一覧を表形式で書き込みます
+func writeVoicesText(w io.Writer, voices []tts.Voice) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "名前\t性別\t言語\tサンプルレート")
+	for _, voice := range voices {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d Hz\n",
+			voice.Name, voice.Gender, strings.Join(voice.LanguageCodes, ","), voice.NaturalSampleRateHertz)
+	}
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d件の声が見つかりました\n", len(voices))
+	return err
+}
+
+// writeVoicesJSON は声の一覧をJSON形式で書き込みます
+func writeVoicesJSON(w io.Writer, voices []tts.Voice) error {
+	if voices == nil {
+		voices = []tts.Voice{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(voices)
+}